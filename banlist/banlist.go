@@ -0,0 +1,247 @@
+package banlist
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	Forbidden   = 0
+	Limited     = 1
+	SemiLimited = 2
+	Unlimited   = 3
+)
+
+// Resolver maps a card code to its canonical code. *carddb.DB and
+// *carddb.Cache both satisfy it.
+type Resolver interface {
+	Canonical(code uint32) (uint32, error)
+}
+
+// List is one named banlist from an lflist.conf file.
+type List struct {
+	Name string
+	// Date is parsed from a leading "YYYY.MM" or "YYYY.MM.DD" in the name;
+	// zero if the name carries no date.
+	Date time.Time
+	// Whitelist lists ($whitelist) only allow the cards they mention.
+	Whitelist bool
+	// Limits maps card code to its allowed copy count.
+	Limits map[uint32]int
+
+	resolver  Resolver
+	canonical map[uint32]int // canonical code -> limit, built by UseResolver
+}
+
+// Limit returns how many copies of code a deck may contain. Codes not on
+// the list are Unlimited, or Forbidden on a whitelist. When a resolver is
+// attached, alternate artworks share the limit of their canonical card.
+func (l *List) Limit(code uint32) int {
+	if n, ok := l.Limits[code]; ok {
+		return n
+	}
+	if l.resolver != nil {
+		if root, err := l.resolver.Canonical(code); err == nil {
+			if n, ok := l.canonical[root]; ok {
+				return n
+			}
+		}
+	}
+	if l.Whitelist {
+		return Forbidden
+	}
+	return Unlimited
+}
+
+// UseResolver enables alias-aware lookups for this list.
+func (l *List) UseResolver(r Resolver) error {
+	canonical := make(map[uint32]int, len(l.Limits))
+	for code, n := range l.Limits {
+		root, err := r.Canonical(code)
+		if err != nil {
+			return fmt.Errorf("banlist %q: %w", l.Name, err)
+		}
+		// Several prints may be listed; the strictest entry wins.
+		if prev, ok := canonical[root]; !ok || n < prev {
+			canonical[root] = n
+		}
+	}
+	l.resolver = r
+	l.canonical = canonical
+	return nil
+}
+
+// Codes returns the listed codes in ascending order.
+func (l *List) Codes() []uint32 {
+	codes := make([]uint32, 0, len(l.Limits))
+	for c := range l.Limits {
+		codes = append(codes, c)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+	return codes
+}
+
+// Collection is every list of one lflist.conf file, in file order.
+type Collection struct {
+	Lists []*List
+}
+
+// Info identifies an available banlist.
+type Info struct {
+	Name string
+	Date time.Time
+}
+
+// Available lists the banlists by name and date, newest first. Undated
+// lists come last in file order.
+func (c *Collection) Available() []Info {
+	infos := make([]Info, 0, len(c.Lists))
+	for _, l := range c.Lists {
+		infos = append(infos, Info{Name: l.Name, Date: l.Date})
+	}
+	sort.SliceStable(infos, func(i, j int) bool {
+		a, b := infos[i].Date, infos[j].Date
+		if a.IsZero() != b.IsZero() {
+			return b.IsZero()
+		}
+		return a.After(b)
+	})
+	return infos
+}
+
+// Find returns the list with the given name, or nil.
+func (c *Collection) Find(name string) *List {
+	for _, l := range c.Lists {
+		if l.Name == name {
+			return l
+		}
+	}
+	return nil
+}
+
+// UseResolver enables alias-aware lookups on every list.
+func (c *Collection) UseResolver(r Resolver) error {
+	for _, l := range c.Lists {
+		if err := l.UseResolver(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func Load(path string) (*Collection, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open banlist: %w", err)
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse reads the lflist.conf format:
+//
+//	#[2024.01 TCG][2024.01 OCG]
+//	!2024.01 TCG
+//	$whitelist
+//	# comment
+//	14558127 1 --Ash Blossom & Joyous Spring
+func Parse(r io.Reader) (*Collection, error) {
+	c := &Collection{}
+	var cur *List
+
+	sc := bufio.NewScanner(r)
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		line := strings.TrimSpace(sc.Text())
+		if lineNo == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+
+		case strings.HasPrefix(line, "!"):
+			name := strings.TrimSpace(line[1:])
+			if name == "" {
+				return nil, fmt.Errorf("lflist line %d: empty banlist name", lineNo)
+			}
+			if c.Find(name) != nil {
+				return nil, fmt.Errorf("lflist line %d: duplicate banlist %q", lineNo, name)
+			}
+			cur = &List{Name: name, Date: parseDate(name), Limits: make(map[uint32]int)}
+			c.Lists = append(c.Lists, cur)
+
+		case strings.HasPrefix(line, "$"):
+			if cur == nil {
+				return nil, fmt.Errorf("lflist line %d: %s before any !name header", lineNo, line)
+			}
+			if strings.EqualFold(strings.Fields(line)[0], "$whitelist") {
+				cur.Whitelist = true
+			}
+
+		default:
+			if cur == nil {
+				return nil, fmt.Errorf("lflist line %d: card entry before any !name header", lineNo)
+			}
+			code, count, err := parseEntry(line)
+			if err != nil {
+				return nil, fmt.Errorf("lflist line %d: %w", lineNo, err)
+			}
+			cur.Limits[code] = count
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read banlist: %w", err)
+	}
+	return c, nil
+}
+
+func parseEntry(line string) (uint32, int, error) {
+	if i := strings.Index(line, "--"); i >= 0 {
+		line = line[:i]
+	}
+	if i := strings.Index(line, "#"); i >= 0 {
+		line = line[:i]
+	}
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return 0, 0, fmt.Errorf("expected \"<code> <count>\", got %q", line)
+	}
+	code, err := strconv.ParseUint(fields[0], 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid card code %q", fields[0])
+	}
+	count, err := strconv.Atoi(fields[1])
+	if err != nil || count < Forbidden || count > Unlimited {
+		return 0, 0, fmt.Errorf("invalid copy count %q for %d", fields[1], code)
+	}
+	return uint32(code), count, nil
+}
+
+var datePrefix = regexp.MustCompile(`^(\d{4})[.\-/](\d{1,2})(?:[.\-/](\d{1,2}))?`)
+
+func parseDate(name string) time.Time {
+	m := datePrefix.FindStringSubmatch(name)
+	if m == nil {
+		return time.Time{}
+	}
+	year, _ := strconv.Atoi(m[1])
+	month, _ := strconv.Atoi(m[2])
+	day := 1
+	if m[3] != "" {
+		day, _ = strconv.Atoi(m[3])
+	}
+	if month < 1 || month > 12 || day < 1 || day > 31 {
+		return time.Time{}
+	}
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
@@ -49,7 +49,10 @@ namespace
 
         [[nodiscard]] auto pile_size(Con, Loc) const noexcept -> std::size_t override
         {
-            // TODO: optionally use OCG_DuelQueryCount to provide accurate sizes.
+            // TODO: track pile sizes per message. The whole batch is encoded
+            // after OCG_DuelProcess, so OCG_DuelQueryCount here would give
+            // every message the end-of-batch size and hide the misreport
+            // from state.CrossCheck, which queries the same live count.
             return 0U;
         }
 
@@ -92,6 +95,30 @@ namespace
         }
     };
 
+    // Core messages that confirm cards to one player. The player byte
+    // follows the message type; YGOpen's encoder drops it, so it is kept
+    // in field kConfirmPlayerField of Meta.Confirm for fog.Redact.
+    constexpr uint8_t MSG_CONFIRM_DECKTOP = 30;
+    constexpr uint8_t MSG_CONFIRM_CARDS = 31;
+    constexpr uint8_t MSG_CONFIRM_EXTRATOP = 143;
+    constexpr int kConfirmPlayerField = 1000;
+
+    void keep_confirm_player(Msg &msg, const uint8_t *raw, uint32_t len)
+    {
+        if (len < 2 || !msg.has_event() || !msg.event().has_meta() ||
+            !msg.event().meta().has_confirm())
+            return;
+        switch (raw[0])
+        {
+        case MSG_CONFIRM_DECKTOP:
+        case MSG_CONFIRM_CARDS:
+        case MSG_CONFIRM_EXTRATOP:
+            msg.mutable_event()->mutable_meta()->mutable_confirm()->mutable_unknown_fields()->AddVarint(
+                kConfirmPlayerField, raw[1]);
+            break;
+        }
+    }
+
     // Simple helper to cast opaque handle back to context.
     inline DuelContext *ctx_from_handle(YGO_DuelHandle handle)
     {
@@ -235,6 +262,7 @@ int ygo_duel_step(YGO_DuelHandle handle)
                 {
                 case YGOpen::Codec::EncodeOneResult::State::OK:
 {
+    keep_confirm_player(*result.msg, msg_start, msg_len);
     std::string serialized;
     if (result.msg->SerializeToString(&serialized))
         ctx->encoded_msgs.push_back(std::move(serialized));
@@ -304,4 +332,47 @@ int ygo_duel_apply_answer(YGO_DuelHandle handle,
 
     OCG_DuelSetResponse(ctx->duel, raw.data(), static_cast<uint32_t>(raw.size()));
     return 0;
-}
\ No newline at end of file
+}
+
+int ygo_duel_query_count(YGO_DuelHandle handle, uint8_t con, uint32_t loc)
+{
+    auto *ctx = ctx_from_handle(handle);
+    if (!ctx || !ctx->duel)
+        return -1;
+    return static_cast<int>(OCG_DuelQueryCount(ctx->duel, con, loc));
+}
+
+int ygo_duel_query_location(YGO_DuelHandle handle,
+                            uint8_t con,
+                            uint32_t loc,
+                            uint32_t flags,
+                            YGO_Buffer *out_buf)
+{
+    auto *ctx = ctx_from_handle(handle);
+    if (!ctx || !ctx->duel || !out_buf)
+        return -1;
+
+    OCG_QueryInfo info{};
+    info.flags = flags;
+    info.con = con;
+    info.loc = loc;
+
+    uint32_t length = 0;
+    auto *data = OCG_DuelQueryLocation(ctx->duel, &length, info);
+    out_buf->data = static_cast<const uint8_t *>(data);
+    out_buf->len = length;
+    return 0;
+}
+
+int ygo_duel_query_field(YGO_DuelHandle handle, YGO_Buffer *out_buf)
+{
+    auto *ctx = ctx_from_handle(handle);
+    if (!ctx || !ctx->duel || !out_buf)
+        return -1;
+
+    uint32_t length = 0;
+    auto *data = OCG_DuelQueryField(ctx->duel, &length);
+    out_buf->data = static_cast<const uint8_t *>(data);
+    out_buf->len = length;
+    return 0;
+}
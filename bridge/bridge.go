@@ -26,14 +26,36 @@ import (
 */
 
 var (
-	globalCardDB *carddb.DB
+	globalCardDB *carddb.Cache
 	globalCardMu sync.RWMutex
 )
 
+// SetCardDB installs the database used by the core's card reader callback.
+// Lookups go through a fresh carddb.Cache; use SetCardCache to share or
+// preload one.
 func SetCardDB(db *carddb.DB) {
+	if db == nil {
+		SetCardCache(nil)
+		return
+	}
+	SetCardCache(carddb.NewCache(db))
+}
+
+func SetCardCache(cache *carddb.Cache) {
 	globalCardMu.Lock()
 	defer globalCardMu.Unlock()
-	globalCardDB = db
+	globalCardDB = cache
+}
+
+// CardCacheStats reports hit/miss counters of the installed card cache.
+func CardCacheStats() carddb.CacheStats {
+	globalCardMu.RLock()
+	cache := globalCardDB
+	globalCardMu.RUnlock()
+	if cache == nil {
+		return carddb.CacheStats{}
+	}
+	return cache.Stats()
 }
 
 /*
@@ -88,6 +110,8 @@ const (
 	LOC_REMOVED = 0x10
 	LOC_MZONE   = 0x4
 	LOC_SZONE   = 0x20
+	LOC_EXTRA   = 0x40
+	LOC_OVERLAY = 0x80
 
 	POS_FACEUP   = 0x5
 	POS_FACEDOWN = 0xa
@@ -339,7 +363,7 @@ func HandleStep(d *Duel) error {
 */
 
 func (d *Duel) SendIdleCardAction(action answerpb.Answer_SelectIdle_Action, index uint32) error {
-	ans := &answerpb.Answer{
+	return d.SendAnswer(&answerpb.Answer{
 		T: &answerpb.Answer_SelectIdle_{
 			SelectIdle: &answerpb.Answer_SelectIdle{
 				T: &answerpb.Answer_SelectIdle_CardAction_{
@@ -350,30 +374,11 @@ func (d *Duel) SendIdleCardAction(action answerpb.Answer_SelectIdle_Action, inde
 				},
 			},
 		},
-	}
-
-	b, err := proto.Marshal(ans)
-	if err != nil {
-		return err
-	}
-	if len(b) == 0 {
-		return nil
-	}
-
-	rc := C.ygo_duel_apply_answer(
-		d.h,
-		(*C.uint8_t)(unsafe.Pointer(&b[0])),
-		C.uint32_t(len(b)),
-	)
-	if rc != 0 {
-		return fmt.Errorf("ygo_duel_apply_answer failed: %d", int(rc))
-	}
-
-	return nil
+	})
 }
 
 func (d *Duel) SendIdlePhase(phase uint32) error {
-	ans := &answerpb.Answer{
+	return d.SendAnswer(&answerpb.Answer{
 		T: &answerpb.Answer_SelectIdle_{
 			SelectIdle: &answerpb.Answer_SelectIdle{
 				T: &answerpb.Answer_SelectIdle_Phase{
@@ -381,29 +386,11 @@ func (d *Duel) SendIdlePhase(phase uint32) error {
 				},
 			},
 		},
-	}
-
-	b, err := proto.Marshal(ans)
-	if err != nil {
-		return err
-	}
-	if len(b) == 0 {
-		return nil
-	}
-
-	rc := C.ygo_duel_apply_answer(
-		d.h,
-		(*C.uint8_t)(unsafe.Pointer(&b[0])),
-		C.uint32_t(len(b)),
-	)
-	if rc != 0 {
-		return fmt.Errorf("ygo_duel_apply_answer failed: %d", int(rc))
-	}
-	return nil
+	})
 }
 
 func (d *Duel) SendSelectToChainNoOp() error {
-	ans := &answerpb.Answer{
+	return d.SendAnswer(&answerpb.Answer{
 		T: &answerpb.Answer_SelectToChain_{
 			SelectToChain: &answerpb.Answer_SelectToChain{
 				T: &answerpb.Answer_SelectToChain_NoOp{
@@ -411,8 +398,11 @@ func (d *Duel) SendSelectToChainNoOp() error {
 				},
 			},
 		},
-	}
+	})
+}
 
+// SendAnswer serializes any Answer and applies it to the pending request.
+func (d *Duel) SendAnswer(ans *answerpb.Answer) error {
 	b, err := proto.Marshal(ans)
 	if err != nil {
 		return err
@@ -431,3 +421,39 @@ func (d *Duel) SendSelectToChainNoOp() error {
 	}
 	return nil
 }
+
+/*
+   Raw core queries (see state.CrossCheck)
+*/
+
+// QueryCount returns the number of cards the core holds at con/loc.
+func (d *Duel) QueryCount(con uint8, loc uint32) int {
+	return int(C.ygo_duel_query_count(d.h, C.uint8_t(con), C.uint32_t(loc)))
+}
+
+// QueryLocation returns the core's raw OCG_DuelQueryLocation buffer for
+// every card at con/loc, with the QUERY_* flags given.
+func (d *Duel) QueryLocation(con uint8, loc, flags uint32) ([]byte, error) {
+	var buf C.YGO_Buffer
+	rc := C.ygo_duel_query_location(d.h, C.uint8_t(con), C.uint32_t(loc), C.uint32_t(flags), &buf)
+	if rc != 0 {
+		return nil, fmt.Errorf("ygo_duel_query_location failed: %d", int(rc))
+	}
+	if buf.len == 0 {
+		return nil, nil
+	}
+	return C.GoBytes(unsafe.Pointer(buf.data), C.int(buf.len)), nil
+}
+
+// QueryField returns the core's raw OCG_DuelQueryField buffer.
+func (d *Duel) QueryField() ([]byte, error) {
+	var buf C.YGO_Buffer
+	rc := C.ygo_duel_query_field(d.h, &buf)
+	if rc != 0 {
+		return nil, fmt.Errorf("ygo_duel_query_field failed: %d", int(rc))
+	}
+	if buf.len == 0 {
+		return nil, nil
+	}
+	return C.GoBytes(unsafe.Pointer(buf.data), C.int(buf.len)), nil
+}
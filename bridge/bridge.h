@@ -80,6 +80,12 @@ int ygo_duel_step(YGO_DuelHandle handle);
 int ygo_duel_next_msg(YGO_DuelHandle handle, YGO_Buffer* out_buf);
 int ygo_duel_apply_answer(YGO_DuelHandle handle, const uint8_t* data, uint32_t len);
 
+/* Raw core queries, used to cross-check the Go-side board. Buffers are
+ * owned by the core and stay valid until the next query or step. */
+int ygo_duel_query_count(YGO_DuelHandle handle, uint8_t con, uint32_t loc);
+int ygo_duel_query_location(YGO_DuelHandle handle, uint8_t con, uint32_t loc, uint32_t flags, YGO_Buffer* out_buf);
+int ygo_duel_query_field(YGO_DuelHandle handle, YGO_Buffer* out_buf);
+
 #ifdef __cplusplus
 }
 #endif
@@ -0,0 +1,165 @@
+package carddb
+
+import (
+	"fmt"
+	"sort"
+)
+
+// maxAliasDepth bounds alias chain walks so a malformed database with an
+// alias cycle cannot loop forever.
+const maxAliasDepth = 16
+
+// artworkRange is how close an alias must be to its card's code for the
+// card to be an alternate artwork, as in ygopro. Farther aliases name the
+// card the text says it is "always treated as", which is a different card
+// for deck building.
+const artworkRange = 10
+
+// isArtwork reports whether a card with alias is an alternate artwork of
+// the card alias, rather than a card treated as its name.
+func isArtwork(code, alias uint32) bool {
+	if code > alias {
+		return code-alias < artworkRange
+	}
+	return alias-code < artworkRange
+}
+
+// Canonical follows the artwork aliases of code and returns the code of
+// its original print. Name aliases are not followed; see TreatedAs. Codes
+// missing from the database are returned unchanged.
+func (d *DB) Canonical(code uint32) (uint32, error) {
+	return canonical(d.GetCard, code)
+}
+
+// Canonical is DB.Canonical served from the cache.
+func (c *Cache) Canonical(code uint32) (uint32, error) {
+	return canonical(c.GetCard, code)
+}
+
+func canonical(get func(uint32) (*CardData, error), code uint32) (uint32, error) {
+	current := code
+	for depth := 0; depth < maxAliasDepth; depth++ {
+		card, err := get(current)
+		if err != nil {
+			return 0, err
+		}
+		if card == nil || card.Alias == 0 || card.Alias == current || !isArtwork(current, card.Alias) {
+			return current, nil
+		}
+		current = card.Alias
+	}
+	return 0, fmt.Errorf("alias chain for code %d exceeds %d links", code, maxAliasDepth)
+}
+
+// TreatedAs returns the original print of the card whose name code is
+// always treated as, such as Harpie Lady for Cyber Harpie Lady. A card
+// without a name alias is treated as its own original print.
+func (d *DB) TreatedAs(code uint32) (uint32, error) {
+	return treatedAs(d.GetCard, code)
+}
+
+// TreatedAs is DB.TreatedAs served from the cache.
+func (c *Cache) TreatedAs(code uint32) (uint32, error) {
+	return treatedAs(c.GetCard, code)
+}
+
+func treatedAs(get func(uint32) (*CardData, error), code uint32) (uint32, error) {
+	current := code
+	for depth := 0; depth < maxAliasDepth; depth++ {
+		root, err := canonical(get, current)
+		if err != nil {
+			return 0, err
+		}
+		card, err := get(root)
+		if err != nil {
+			return 0, err
+		}
+		if card == nil || card.Alias == 0 || card.Alias == root {
+			return root, nil
+		}
+		current = card.Alias
+	}
+	return 0, fmt.Errorf("alias chain for code %d exceeds %d links", code, maxAliasDepth)
+}
+
+// Artworks lists every alternate artwork of code's card, including the
+// original print, in ascending order. Cards only treated as its name are
+// not included.
+func (d *DB) Artworks(code uint32) ([]uint32, error) {
+	root, err := d.Canonical(code)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[uint32]bool{root: true}
+	queue := []uint32{root}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		aliases, err := d.codesAliasing(current)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range aliases {
+			if isArtwork(a, current) && !seen[a] {
+				seen[a] = true
+				queue = append(queue, a)
+			}
+		}
+	}
+
+	codes := make([]uint32, 0, len(seen))
+	for c := range seen {
+		codes = append(codes, c)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+	return codes, nil
+}
+
+func (d *DB) codesAliasing(code uint32) ([]uint32, error) {
+	rows, err := d.db.Query(`SELECT id FROM datas WHERE alias = ?`, code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query aliases of %d: %w", code, err)
+	}
+	defer rows.Close()
+
+	var codes []uint32
+	for rows.Next() {
+		var c uint32
+		if err := rows.Scan(&c); err != nil {
+			return nil, fmt.Errorf("failed scanning alias row: %w", err)
+		}
+		codes = append(codes, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating alias rows: %w", err)
+	}
+	return codes, nil
+}
+
+// SameCard reports whether a and b are the same card for deck-building
+// purposes, i.e. artworks of the same original print.
+func (d *DB) SameCard(a, b uint32) (bool, error) {
+	return sameCard(d.Canonical, a, b)
+}
+
+// SameCard is DB.SameCard served from the cache.
+func (c *Cache) SameCard(a, b uint32) (bool, error) {
+	return sameCard(c.Canonical, a, b)
+}
+
+func sameCard(resolve func(uint32) (uint32, error), a, b uint32) (bool, error) {
+	if a == b {
+		return true, nil
+	}
+	ca, err := resolve(a)
+	if err != nil {
+		return false, err
+	}
+	cb, err := resolve(b)
+	if err != nil {
+		return false, err
+	}
+	return ca == cb, nil
+}
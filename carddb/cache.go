@@ -0,0 +1,143 @@
+package carddb
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Cache is a concurrent-safe, in-memory layer in front of DB.GetCard.
+//
+// The core asks for the same codes over and over during a duel, and every
+// parallel duel shares the same card reader, so lookups are served from a
+// map after the first query. Codes missing from the database are cached too.
+//
+// Returned *CardData values are shared between callers and must not be
+// modified.
+type Cache struct {
+	db *DB
+
+	mu    sync.RWMutex
+	cards map[uint32]*CardData // nil value: code is known to be missing
+	texts map[uint32]*CardText // nil value: code has no texts row
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// CacheStats is a snapshot of the cache counters.
+type CacheStats struct {
+	Hits    uint64
+	Misses  uint64
+	Entries int
+}
+
+// HitRate returns hits / (hits + misses), or 0 when nothing was looked up.
+func (s CacheStats) HitRate() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+func NewCache(db *DB) *Cache {
+	return &Cache{
+		db:    db,
+		cards: make(map[uint32]*CardData),
+		texts: make(map[uint32]*CardText),
+	}
+}
+
+// GetCard behaves like DB.GetCard but answers repeated lookups from memory.
+func (c *Cache) GetCard(code uint32) (*CardData, error) {
+	c.mu.RLock()
+	card, ok := c.cards[code]
+	c.mu.RUnlock()
+	if ok {
+		c.hits.Add(1)
+		return card, nil
+	}
+
+	c.misses.Add(1)
+	card, err := c.db.GetCard(code)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.cards[code] = card
+	c.mu.Unlock()
+	return card, nil
+}
+
+// GetText behaves like DB.GetText but answers repeated lookups from memory.
+// Text lookups are not counted in Stats.
+func (c *Cache) GetText(code uint32) (*CardText, error) {
+	c.mu.RLock()
+	text, ok := c.texts[code]
+	c.mu.RUnlock()
+	if ok {
+		return text, nil
+	}
+
+	text, err := c.db.GetText(code)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.texts[code] = text
+	c.mu.Unlock()
+	return text, nil
+}
+
+// Preload reads the whole datas table into memory and returns the number of
+// cards loaded. Existing entries are replaced.
+func (c *Cache) Preload() (int, error) {
+	cards, err := c.db.AllCards()
+	if err != nil {
+		return 0, fmt.Errorf("failed to preload card cache: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, card := range cards {
+		c.cards[card.Code] = card
+	}
+	return len(cards), nil
+}
+
+// Invalidate drops a single code so the next lookup re-reads the database.
+func (c *Cache) Invalidate(code uint32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.cards, code)
+	delete(c.texts, code)
+}
+
+// Clear drops every cached entry and resets the counters.
+func (c *Cache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cards = make(map[uint32]*CardData)
+	c.texts = make(map[uint32]*CardText)
+	c.hits.Store(0)
+	c.misses.Store(0)
+}
+
+func (c *Cache) Stats() CacheStats {
+	c.mu.RLock()
+	entries := len(c.cards)
+	c.mu.RUnlock()
+	return CacheStats{
+		Hits:    c.hits.Load(),
+		Misses:  c.misses.Load(),
+		Entries: entries,
+	}
+}
+
+// DB returns the database behind the cache.
+func (c *Cache) DB() *DB {
+	return c.db
+}
@@ -0,0 +1,91 @@
+package carddb
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// benchCards is how many cards the benchmark database holds.
+const benchCards = 2000
+
+// benchDB creates a cdb with benchCards normal monsters in a temporary
+// directory.
+func benchDB(b *testing.B) *DB {
+	b.Helper()
+	db, err := Create(filepath.Join(b.TempDir(), "bench.cdb"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(db.Close)
+
+	recs := make([]*CardRecord, benchCards)
+	for i := range recs {
+		recs[i] = &CardRecord{
+			CardData: CardData{
+				Code:    uint32(10000 + i),
+				Type:    TYPE_MONSTER | TYPE_NORMAL,
+				Level:   4,
+				Attack:  1500,
+				Defense: 1200,
+			},
+			Text: CardText{Name: "Bench Monster"},
+		}
+	}
+	if err := NewWriter(db).Import(recs); err != nil {
+		b.Fatal(err)
+	}
+	return db
+}
+
+func benchCode(i int) uint32 {
+	return uint32(10000 + i%benchCards)
+}
+
+func BenchmarkDBGetCard(b *testing.B) {
+	db := benchDB(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := db.GetCard(benchCode(i)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkCacheGetCardCold measures a first lookup: every iteration
+// starts from an empty cache.
+func BenchmarkCacheGetCardCold(b *testing.B) {
+	db := benchDB(b)
+	c := NewCache(db)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		code := benchCode(i)
+		c.Invalidate(code)
+		if _, err := c.GetCard(code); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCacheGetCardWarm(b *testing.B) {
+	db := benchDB(b)
+	c := NewCache(db)
+	if _, err := c.Preload(); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.GetCard(benchCode(i)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCachePreload(b *testing.B) {
+	db := benchDB(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := NewCache(db).Preload(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
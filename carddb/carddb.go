@@ -3,6 +3,7 @@ package carddb
 import (
 	"database/sql"
 	"fmt"
+	"os"
 
 	_ "modernc.org/sqlite"
 )
@@ -19,34 +20,82 @@ type CardData struct {
 	Lscale     uint32
 	Rscale     uint32
 	LinkMarker uint32
+	OT         OT
+	Category   Category
 }
 
 type DB struct {
-	db *sql.DB
+	db     *sql.DB
+	schema *SchemaInfo
 }
 
+// Source is the read-only card lookup shared by *DB and *Cache, used by
+// packages that only need card data, texts and alias resolution.
+type Source interface {
+	GetCard(code uint32) (*CardData, error)
+	GetText(code uint32) (*CardText, error)
+	Canonical(code uint32) (uint32, error)
+}
+
+// Open opens an existing cdb file and checks that it has the tables and
+// columns this package reads. A wrong file fails here rather than at the
+// first card callback; the error wraps ErrInvalidSchema when the file is a
+// sqlite database with the wrong layout.
 func Open(path string) (*DB, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("failed to open card db: %w", err)
+	}
+
 	db, err := sql.Open("sqlite", path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open card db: %w", err)
 	}
-	return &DB{db: db}, nil
+
+	d := &DB{db: db}
+	schema, err := d.inspectSchema()
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s is not a usable card database: %w", path, err)
+	}
+	d.schema = schema
+	return d, nil
 }
 
 func (d *DB) Close() {
 	d.db.Close()
 }
 
+const cardColumns = `id, alias, type, level,
+		       attribute, race,
+		       atk, def,
+		       ot, category`
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
 func (d *DB) GetCard(code uint32) (*CardData, error) {
 	row := d.db.QueryRow(`
-		SELECT id, alias, type, level,
-		       attribute, race,
-		       atk, def
+		SELECT `+cardColumns+`
 		FROM datas
 		WHERE id = ?`, code)
 
+	c, err := scanCard(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query failed for code %d: %w", code, err)
+	}
+	return c, nil
+}
+
+func scanCard(row rowScanner) (*CardData, error) {
 	var c CardData
 	var rawLevel uint32
+	// race and category are 64-bit flag sets that sqlite stores signed.
+	var race, category int64
 
 	err := row.Scan(
 		&c.Code,
@@ -54,17 +103,17 @@ func (d *DB) GetCard(code uint32) (*CardData, error) {
 		&c.Type,
 		&rawLevel,
 		&c.Attribute,
-		&c.Race,
+		&race,
 		&c.Attack,
 		&c.Defense,
+		&c.OT,
+		&category,
 	)
-
-	if err == sql.ErrNoRows {
-		return nil, nil
-	}
 	if err != nil {
-		return nil, fmt.Errorf("query failed for code %d: %w", code, err)
+		return nil, err
 	}
+	c.Race = uint64(race)
+	c.Category = Category(category)
 
 	// =========================
 	// Decode Level & Pendulum
@@ -81,9 +130,6 @@ func (d *DB) GetCard(code uint32) (*CardData, error) {
 	// Decode Link Monsters
 	// =========================
 
-	// TYPE_LINK = 0x4000000
-	const TYPE_LINK = 0x4000000
-
 	if c.Type&TYPE_LINK != 0 {
 		// For link monsters:
 		// - Defense field stores link marker bitmask
@@ -96,6 +142,30 @@ func (d *DB) GetCard(code uint32) (*CardData, error) {
 	return &c, nil
 }
 
+// AllCards reads every row of the datas table.
+func (d *DB) AllCards() ([]*CardData, error) {
+	rows, err := d.db.Query(`
+		SELECT ` + cardColumns + `
+		FROM datas`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query cards: %w", err)
+	}
+	defer rows.Close()
+
+	var cards []*CardData
+	for rows.Next() {
+		c, err := scanCard(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed scanning card row: %w", err)
+		}
+		cards = append(cards, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating card rows: %w", err)
+	}
+	return cards, nil
+}
+
 func (d *DB) GetSchema() (string, error) {
 	rows, err := d.db.Query(`
 		SELECT type, name, sql
@@ -0,0 +1,163 @@
+package carddb
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// DiffReport lists what changed between two card databases, keyed by code.
+type DiffReport struct {
+	Added   []CardSummary `json:"added"`
+	Removed []CardSummary `json:"removed"`
+	Changed []CardChange  `json:"changed"`
+}
+
+type CardSummary struct {
+	Code uint32 `json:"code"`
+	Name string `json:"name"`
+}
+
+// CardChange holds the field-level differences of one card present in both
+// databases.
+type CardChange struct {
+	CardSummary
+	Fields []FieldChange `json:"fields"`
+}
+
+// FieldChange is one differing field. Values are rendered in their decoded
+// form (level and scales split, link markers, flag names).
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+func (r *DiffReport) Empty() bool {
+	return len(r.Added) == 0 && len(r.Removed) == 0 && len(r.Changed) == 0
+}
+
+// Diff compares every card of a (old) against b (new).
+func Diff(a, b *DB) (*DiffReport, error) {
+	oldRecs, err := a.AllRecords()
+	if err != nil {
+		return nil, err
+	}
+	newRecs, err := b.AllRecords()
+	if err != nil {
+		return nil, err
+	}
+	return DiffRecords(oldRecs, newRecs), nil
+}
+
+// DiffRecords compares two record sets by code.
+func DiffRecords(oldRecs, newRecs []*CardRecord) *DiffReport {
+	oldByCode := make(map[uint32]*CardRecord, len(oldRecs))
+	for _, r := range oldRecs {
+		oldByCode[r.Code] = r
+	}
+	newByCode := make(map[uint32]*CardRecord, len(newRecs))
+	for _, r := range newRecs {
+		newByCode[r.Code] = r
+	}
+
+	report := &DiffReport{
+		Added:   []CardSummary{},
+		Removed: []CardSummary{},
+		Changed: []CardChange{},
+	}
+	for code, o := range oldByCode {
+		n, ok := newByCode[code]
+		if !ok {
+			report.Removed = append(report.Removed, summarize(o))
+			continue
+		}
+		if fields := diffFields(o, n); len(fields) > 0 {
+			report.Changed = append(report.Changed, CardChange{CardSummary: summarize(n), Fields: fields})
+		}
+	}
+	for code, n := range newByCode {
+		if _, ok := oldByCode[code]; !ok {
+			report.Added = append(report.Added, summarize(n))
+		}
+	}
+
+	sort.Slice(report.Added, func(i, j int) bool { return report.Added[i].Code < report.Added[j].Code })
+	sort.Slice(report.Removed, func(i, j int) bool { return report.Removed[i].Code < report.Removed[j].Code })
+	sort.Slice(report.Changed, func(i, j int) bool { return report.Changed[i].Code < report.Changed[j].Code })
+	return report
+}
+
+func summarize(r *CardRecord) CardSummary {
+	return CardSummary{Code: r.Code, Name: r.Text.Name}
+}
+
+func diffFields(o, n *CardRecord) []FieldChange {
+	var fields []FieldChange
+	add := func(field string, old, new any) {
+		before, after := fmt.Sprint(old), fmt.Sprint(new)
+		if before != after {
+			fields = append(fields, FieldChange{Field: field, Old: before, New: after})
+		}
+	}
+	hex := func(v uint64) string { return fmt.Sprintf("0x%x", v) }
+
+	add("name", o.Text.Name, n.Text.Name)
+	add("alias", o.Alias, n.Alias)
+	add("type", hex(uint64(o.Type)), hex(uint64(n.Type)))
+	add("level", o.Level, n.Level)
+	add("lscale", o.Lscale, n.Lscale)
+	add("rscale", o.Rscale, n.Rscale)
+	add("link_markers", hex(uint64(o.LinkMarker)), hex(uint64(n.LinkMarker)))
+	add("atk", o.Attack, n.Attack)
+	add("def", o.Defense, n.Defense)
+	add("race", hex(o.Race), hex(n.Race))
+	add("attribute", hex(uint64(o.Attribute)), hex(uint64(n.Attribute)))
+	add("ot", o.OT, n.OT)
+	add("category", o.Category, n.Category)
+	add("setcodes", setcodeList(o.Setcodes), setcodeList(n.Setcodes))
+	add("desc", o.Text.Desc, n.Text.Desc)
+	for i := 0; i < StringSlots; i++ {
+		add(fmt.Sprintf("str%d", i+1), o.Text.Strings[i], n.Text.Strings[i])
+	}
+	return fields
+}
+
+func setcodeList(setcodes []uint16) string {
+	s := "["
+	for i, sc := range setcodes {
+		if i > 0 {
+			s += " "
+		}
+		s += fmt.Sprintf("0x%x", sc)
+	}
+	return s + "]"
+}
+
+// WriteText prints the report in a diff-like layout: one line per card
+// prefixed with "+" (added), "-" (removed) or "~" (changed), changed fields
+// indented below their card, and a summary line at the end.
+func (r *DiffReport) WriteText(w io.Writer) error {
+	for _, c := range r.Added {
+		if _, err := fmt.Fprintf(w, "+ %d %s\n", c.Code, c.Name); err != nil {
+			return err
+		}
+	}
+	for _, c := range r.Removed {
+		if _, err := fmt.Fprintf(w, "- %d %s\n", c.Code, c.Name); err != nil {
+			return err
+		}
+	}
+	for _, c := range r.Changed {
+		if _, err := fmt.Fprintf(w, "~ %d %s\n", c.Code, c.Name); err != nil {
+			return err
+		}
+		for _, f := range c.Fields {
+			if _, err := fmt.Fprintf(w, "    %s: %q -> %q\n", f.Field, f.Old, f.New); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d added, %d removed, %d changed\n", len(r.Added), len(r.Removed), len(r.Changed))
+	return err
+}
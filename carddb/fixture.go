@@ -0,0 +1,290 @@
+package carddb
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FixtureVersion is written into every exported fixture file.
+const FixtureVersion = 1
+
+// Fixture is the on-disk JSON/YAML form of a set of cards. It stores the
+// decoded values (level and scales, link markers, setcode list, named
+// flags) rather than packed cdb columns so diffs stay readable.
+type Fixture struct {
+	Version int           `json:"version" yaml:"version"`
+	Cards   []FixtureCard `json:"cards" yaml:"cards"`
+}
+
+type FixtureCard struct {
+	Code        uint32   `json:"code" yaml:"code"`
+	Alias       uint32   `json:"alias,omitempty" yaml:"alias,omitempty"`
+	Name        string   `json:"name" yaml:"name"`
+	Desc        string   `json:"desc,omitempty" yaml:"desc,omitempty"`
+	Strings     []string `json:"strings,omitempty" yaml:"strings,omitempty"`
+	OT          []string `json:"ot,omitempty" yaml:"ot,omitempty,flow"`
+	Setcodes    []uint16 `json:"setcodes,omitempty" yaml:"setcodes,omitempty,flow"`
+	Type        uint32   `json:"type" yaml:"type"`
+	Attack      int32    `json:"atk" yaml:"atk"`
+	Defense     int32    `json:"def,omitempty" yaml:"def,omitempty"`
+	Level       uint32   `json:"level,omitempty" yaml:"level,omitempty"`
+	Lscale      uint32   `json:"lscale,omitempty" yaml:"lscale,omitempty"`
+	Rscale      uint32   `json:"rscale,omitempty" yaml:"rscale,omitempty"`
+	LinkMarkers uint32   `json:"link_markers,omitempty" yaml:"link_markers,omitempty"`
+	Race        uint64   `json:"race,omitempty" yaml:"race,omitempty"`
+	Attribute   uint32   `json:"attribute,omitempty" yaml:"attribute,omitempty"`
+	Category    []string `json:"category,omitempty" yaml:"category,omitempty,flow"`
+}
+
+// NewFixture converts records into a Fixture sorted by code.
+func NewFixture(recs []*CardRecord) *Fixture {
+	f := &Fixture{Version: FixtureVersion, Cards: make([]FixtureCard, 0, len(recs))}
+	for _, rec := range recs {
+		f.Cards = append(f.Cards, fixtureCard(rec))
+	}
+	sort.Slice(f.Cards, func(i, j int) bool { return f.Cards[i].Code < f.Cards[j].Code })
+	return f
+}
+
+func fixtureCard(rec *CardRecord) FixtureCard {
+	fc := FixtureCard{
+		Code:        rec.Code,
+		Alias:       rec.Alias,
+		Name:        rec.Text.Name,
+		Desc:        rec.Text.Desc,
+		Setcodes:    rec.Setcodes,
+		Type:        rec.Type,
+		Attack:      rec.Attack,
+		Defense:     rec.Defense,
+		Level:       rec.Level,
+		Lscale:      rec.Lscale,
+		Rscale:      rec.Rscale,
+		LinkMarkers: rec.LinkMarker,
+		Race:        rec.Race,
+		Attribute:   rec.Attribute,
+		OT:          rec.OT.Names(),
+		Category:    rec.Category.Names(),
+	}
+
+	// Keep strN positions but drop the empty tail.
+	last := -1
+	for i, s := range rec.Text.Strings {
+		if s != "" {
+			last = i
+		}
+	}
+	if last >= 0 {
+		fc.Strings = append([]string(nil), rec.Text.Strings[:last+1]...)
+	}
+	return fc
+}
+
+// Records converts the fixture back into card records.
+func (f *Fixture) Records() ([]*CardRecord, error) {
+	if f.Version != FixtureVersion {
+		return nil, fmt.Errorf("unsupported card fixture version %d (want %d)", f.Version, FixtureVersion)
+	}
+
+	recs := make([]*CardRecord, 0, len(f.Cards))
+	seen := make(map[uint32]bool, len(f.Cards))
+	for _, fc := range f.Cards {
+		if seen[fc.Code] {
+			return nil, fmt.Errorf("card %d appears twice in fixture", fc.Code)
+		}
+		seen[fc.Code] = true
+
+		if len(fc.Strings) > StringSlots {
+			return nil, fmt.Errorf("card %d: %d strings, at most %d allowed", fc.Code, len(fc.Strings), StringSlots)
+		}
+		ot, err := parseOT(fc.OT)
+		if err != nil {
+			return nil, fmt.Errorf("card %d: %w", fc.Code, err)
+		}
+		cat, err := parseCategory(fc.Category)
+		if err != nil {
+			return nil, fmt.Errorf("card %d: %w", fc.Code, err)
+		}
+		if fc.Type&TYPE_LINK != 0 && fc.Defense != 0 {
+			return nil, fmt.Errorf("card %d: link monsters have no DEF, use link_markers", fc.Code)
+		}
+
+		rec := &CardRecord{
+			CardData: CardData{
+				Code:       fc.Code,
+				Alias:      fc.Alias,
+				Type:       fc.Type,
+				Level:      fc.Level,
+				Attribute:  fc.Attribute,
+				Race:       fc.Race,
+				Attack:     fc.Attack,
+				Defense:    fc.Defense,
+				Lscale:     fc.Lscale,
+				Rscale:     fc.Rscale,
+				LinkMarker: fc.LinkMarkers,
+				OT:         ot,
+				Category:   cat,
+			},
+			Setcodes: fc.Setcodes,
+			Text:     CardText{Name: fc.Name, Desc: fc.Desc},
+		}
+		copy(rec.Text.Strings[:], fc.Strings)
+		recs = append(recs, rec)
+	}
+	return recs, nil
+}
+
+/*
+   Encoding
+*/
+
+func EncodeJSON(w io.Writer, recs []*CardRecord) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(NewFixture(recs))
+}
+
+func EncodeYAML(w io.Writer, recs []*CardRecord) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(NewFixture(recs)); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func DecodeJSON(r io.Reader) ([]*CardRecord, error) {
+	var f Fixture
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&f); err != nil {
+		return nil, fmt.Errorf("failed to decode card fixture: %w", err)
+	}
+	return f.Records()
+}
+
+func DecodeYAML(r io.Reader) ([]*CardRecord, error) {
+	var f Fixture
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil {
+		return nil, fmt.Errorf("failed to decode card fixture: %w", err)
+	}
+	return f.Records()
+}
+
+/*
+   DB / Writer integration
+*/
+
+// Records reads the full record of every code, failing on unknown codes.
+func (d *DB) Records(codes []uint32) ([]*CardRecord, error) {
+	recs := make([]*CardRecord, 0, len(codes))
+	for _, code := range codes {
+		rec, err := d.GetRecord(code)
+		if err != nil {
+			return nil, err
+		}
+		if rec == nil {
+			return nil, fmt.Errorf("card %d not found", code)
+		}
+		recs = append(recs, rec)
+	}
+	return recs, nil
+}
+
+func (d *DB) ExportJSON(w io.Writer, codes []uint32) error {
+	recs, err := d.Records(codes)
+	if err != nil {
+		return err
+	}
+	return EncodeJSON(w, recs)
+}
+
+func (d *DB) ExportYAML(w io.Writer, codes []uint32) error {
+	recs, err := d.Records(codes)
+	if err != nil {
+		return err
+	}
+	return EncodeYAML(w, recs)
+}
+
+// ImportJSON decodes a fixture and writes it in one transaction.
+func (w *Writer) ImportJSON(r io.Reader) error {
+	recs, err := DecodeJSON(r)
+	if err != nil {
+		return err
+	}
+	return w.Import(recs)
+}
+
+// ImportYAML decodes a fixture and writes it in one transaction.
+func (w *Writer) ImportYAML(r io.Reader) error {
+	recs, err := DecodeYAML(r)
+	if err != nil {
+		return err
+	}
+	return w.Import(recs)
+}
+
+/*
+   Flag name round-tripping
+*/
+
+func parseOT(names []string) (OT, error) {
+	var o OT
+	for _, name := range names {
+		if bits, ok := parseHexFlag(name); ok {
+			o |= OT(bits)
+			continue
+		}
+		found := false
+		for _, n := range otNames {
+			if n.name == name {
+				o |= n.flag
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("unknown ot flag %q", name)
+		}
+	}
+	return o, nil
+}
+
+func parseCategory(names []string) (Category, error) {
+	var c Category
+	for _, name := range names {
+		if bits, ok := parseHexFlag(name); ok {
+			c |= Category(bits)
+			continue
+		}
+		found := false
+		for _, n := range categoryNames {
+			if n.name == name {
+				c |= n.flag
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("unknown category %q", name)
+		}
+	}
+	return c, nil
+}
+
+// parseHexFlag accepts the "0x..." form Names uses for unnamed bits.
+func parseHexFlag(name string) (uint64, bool) {
+	if !strings.HasPrefix(name, "0x") {
+		return 0, false
+	}
+	bits, err := strconv.ParseUint(name[2:], 16, 64)
+	return bits, err == nil
+}
@@ -0,0 +1,124 @@
+package carddb
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// fixtureRecords covers the packed columns: pendulum scales, link
+// markers, texts with a gap in the strings, and flags with the top bit set
+// that only round-trip through the hex fallback.
+func fixtureRecords() []*CardRecord {
+	pendulum := &CardRecord{
+		CardData: CardData{
+			Code:      14,
+			Type:      TYPE_MONSTER | TYPE_EFFECT | TYPE_PENDULUM,
+			Level:     7,
+			Lscale:    1,
+			Rscale:    8,
+			Attribute: 0x20,
+			Race:      1<<63 | 0x1,
+			Attack:    2500,
+			Defense:   2000,
+			OT:        0x3,
+			Category:  1<<63 | 0x1,
+		},
+		Setcodes: []uint16{0x10f2, 0x8001},
+		Text: CardText{
+			Name: "Test Pendulum",
+			Desc: "A card.",
+		},
+	}
+	pendulum.Text.Strings[0] = "first"
+	pendulum.Text.Strings[2] = "third"
+
+	link := &CardRecord{
+		CardData: CardData{
+			Code:       25,
+			Alias:      24,
+			Type:       TYPE_MONSTER | TYPE_EFFECT | TYPE_LINK,
+			Level:      3,
+			Attack:     2300,
+			LinkMarker: 0x1 | 0x4 | 0x100,
+			OT:         0x1 | 0x400,
+		},
+		Setcodes: []uint16{0xffff, 0x1, 0x2, 0x8000},
+		Text:     CardText{Name: "Test Link"},
+	}
+	return []*CardRecord{pendulum, link}
+}
+
+// roundTrip writes recs to a new cdb and reads them back.
+func roundTrip(t *testing.T, recs []*CardRecord) []*CardRecord {
+	t.Helper()
+	db, err := Create(filepath.Join(t.TempDir(), "cards.cdb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := NewWriter(db).Import(recs); err != nil {
+		t.Fatal(err)
+	}
+	got, err := db.AllRecords()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rec := range recs {
+		one, err := db.GetRecord(rec.Code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(one, rec) {
+			t.Errorf("GetRecord(%d) = %+v, want %+v", rec.Code, one, rec)
+		}
+	}
+	return got
+}
+
+func TestFixtureRoundTrip(t *testing.T) {
+	codecs := []struct {
+		name   string
+		encode func(*bytes.Buffer, []*CardRecord) error
+		decode func(*bytes.Buffer) ([]*CardRecord, error)
+	}{
+		{
+			"json",
+			func(b *bytes.Buffer, recs []*CardRecord) error { return EncodeJSON(b, recs) },
+			func(b *bytes.Buffer) ([]*CardRecord, error) { return DecodeJSON(b) },
+		},
+		{
+			"yaml",
+			func(b *bytes.Buffer, recs []*CardRecord) error { return EncodeYAML(b, recs) },
+			func(b *bytes.Buffer) ([]*CardRecord, error) { return DecodeYAML(b) },
+		},
+	}
+	for _, c := range codecs {
+		t.Run(c.name, func(t *testing.T) {
+			want := fixtureRecords()
+			stored := roundTrip(t, want)
+			if !reflect.DeepEqual(stored, want) {
+				t.Fatalf("cdb round trip:\ngot  %+v\nwant %+v", stored, want)
+			}
+
+			var buf bytes.Buffer
+			if err := c.encode(&buf, stored); err != nil {
+				t.Fatal(err)
+			}
+			text := buf.String()
+			decoded, err := c.decode(&buf)
+			if err != nil {
+				t.Fatalf("decode: %v\n%s", err, text)
+			}
+			if !reflect.DeepEqual(decoded, want) {
+				t.Fatalf("fixture round trip:\ngot  %+v\nwant %+v\n%s", decoded, want, text)
+			}
+
+			again := roundTrip(t, decoded)
+			if !reflect.DeepEqual(NewFixture(again), NewFixture(want)) {
+				t.Errorf("fixture to cdb: got %+v, want %+v", NewFixture(again), NewFixture(want))
+			}
+		})
+	}
+}
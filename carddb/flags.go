@@ -0,0 +1,430 @@
+package carddb
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Card type bits of datas.type.
+const (
+	TYPE_MONSTER     = 0x1
+	TYPE_SPELL       = 0x2
+	TYPE_TRAP        = 0x4
+	TYPE_NORMAL      = 0x10
+	TYPE_EFFECT      = 0x20
+	TYPE_FUSION      = 0x40
+	TYPE_RITUAL      = 0x80
+	TYPE_TRAPMONSTER = 0x100
+	TYPE_SPIRIT      = 0x200
+	TYPE_UNION       = 0x400
+	TYPE_GEMINI      = 0x800
+	TYPE_TUNER       = 0x1000
+	TYPE_SYNCHRO     = 0x2000
+	TYPE_TOKEN       = 0x4000
+	TYPE_QUICKPLAY   = 0x10000
+	TYPE_CONTINUOUS  = 0x20000
+	TYPE_EQUIP       = 0x40000
+	TYPE_FIELD       = 0x80000
+	TYPE_COUNTER     = 0x100000
+	TYPE_FLIP        = 0x200000
+	TYPE_TOON        = 0x400000
+	TYPE_XYZ         = 0x800000
+	TYPE_PENDULUM    = 0x1000000
+	TYPE_SPSUMMON    = 0x2000000
+	// TYPE_LINK marks link monsters, whose def column holds link markers.
+	TYPE_LINK = 0x4000000
+
+	// TYPE_EXTRA covers the monsters that live in the Extra Deck.
+	TYPE_EXTRA = TYPE_FUSION | TYPE_SYNCHRO | TYPE_XYZ | TYPE_LINK
+)
+
+func (c *CardData) IsMonster() bool {
+	return c.Type&TYPE_MONSTER != 0
+}
+
+func (c *CardData) IsSpell() bool {
+	return c.Type&TYPE_SPELL != 0
+}
+
+func (c *CardData) IsTrap() bool {
+	return c.Type&TYPE_TRAP != 0
+}
+
+// IsExtraDeck reports whether the card belongs in the Extra Deck.
+func (c *CardData) IsExtraDeck() bool {
+	return c.Type&TYPE_EXTRA != 0
+}
+
+func (c *CardData) IsToken() bool {
+	return c.Type&TYPE_TOKEN != 0
+}
+
+// Attribute bits of datas.attribute.
+const (
+	ATTRIBUTE_EARTH  = 0x1
+	ATTRIBUTE_WATER  = 0x2
+	ATTRIBUTE_FIRE   = 0x4
+	ATTRIBUTE_WIND   = 0x8
+	ATTRIBUTE_LIGHT  = 0x10
+	ATTRIBUTE_DARK   = 0x20
+	ATTRIBUTE_DIVINE = 0x40
+)
+
+// AttributeNames lists every attribute bit with its name, lowest first.
+var AttributeNames = []struct {
+	Flag uint32
+	Name string
+}{
+	{ATTRIBUTE_EARTH, "EARTH"},
+	{ATTRIBUTE_WATER, "WATER"},
+	{ATTRIBUTE_FIRE, "FIRE"},
+	{ATTRIBUTE_WIND, "WIND"},
+	{ATTRIBUTE_LIGHT, "LIGHT"},
+	{ATTRIBUTE_DARK, "DARK"},
+	{ATTRIBUTE_DIVINE, "DIVINE"},
+}
+
+// Race (monster type) bits of datas.race.
+const (
+	RACE_WARRIOR          = 0x1
+	RACE_SPELLCASTER      = 0x2
+	RACE_FAIRY            = 0x4
+	RACE_FIEND            = 0x8
+	RACE_ZOMBIE           = 0x10
+	RACE_MACHINE          = 0x20
+	RACE_AQUA             = 0x40
+	RACE_PYRO             = 0x80
+	RACE_ROCK             = 0x100
+	RACE_WINGEDBEAST      = 0x200
+	RACE_PLANT            = 0x400
+	RACE_INSECT           = 0x800
+	RACE_THUNDER          = 0x1000
+	RACE_DRAGON           = 0x2000
+	RACE_BEAST            = 0x4000
+	RACE_BEASTWARRIOR     = 0x8000
+	RACE_DINOSAUR         = 0x10000
+	RACE_FISH             = 0x20000
+	RACE_SEASERPENT       = 0x40000
+	RACE_REPTILE          = 0x80000
+	RACE_PSYCHIC          = 0x100000
+	RACE_DIVINE           = 0x200000
+	RACE_CREATORGOD       = 0x400000
+	RACE_WYRM             = 0x800000
+	RACE_CYBERSE          = 0x1000000
+	RACE_ILLUSION         = 0x2000000
+	RACE_CYBORG           = 0x4000000
+	RACE_MAGICALKNIGHT    = 0x8000000
+	RACE_HIGHDRAGON       = 0x10000000
+	RACE_OMEGAPSYCHIC     = 0x20000000
+	RACE_CELESTIALWARRIOR = 0x40000000
+	RACE_GALAXY           = 0x80000000
+)
+
+// RaceNames lists every race bit with its name, lowest first.
+var RaceNames = []struct {
+	Flag uint64
+	Name string
+}{
+	{RACE_WARRIOR, "Warrior"},
+	{RACE_SPELLCASTER, "Spellcaster"},
+	{RACE_FAIRY, "Fairy"},
+	{RACE_FIEND, "Fiend"},
+	{RACE_ZOMBIE, "Zombie"},
+	{RACE_MACHINE, "Machine"},
+	{RACE_AQUA, "Aqua"},
+	{RACE_PYRO, "Pyro"},
+	{RACE_ROCK, "Rock"},
+	{RACE_WINGEDBEAST, "Winged Beast"},
+	{RACE_PLANT, "Plant"},
+	{RACE_INSECT, "Insect"},
+	{RACE_THUNDER, "Thunder"},
+	{RACE_DRAGON, "Dragon"},
+	{RACE_BEAST, "Beast"},
+	{RACE_BEASTWARRIOR, "Beast-Warrior"},
+	{RACE_DINOSAUR, "Dinosaur"},
+	{RACE_FISH, "Fish"},
+	{RACE_SEASERPENT, "Sea Serpent"},
+	{RACE_REPTILE, "Reptile"},
+	{RACE_PSYCHIC, "Psychic"},
+	{RACE_DIVINE, "Divine-Beast"},
+	{RACE_CREATORGOD, "Creator God"},
+	{RACE_WYRM, "Wyrm"},
+	{RACE_CYBERSE, "Cyberse"},
+	{RACE_ILLUSION, "Illusion"},
+	{RACE_CYBORG, "Cyborg"},
+	{RACE_MAGICALKNIGHT, "Magical Knight"},
+	{RACE_HIGHDRAGON, "High Dragon"},
+	{RACE_OMEGAPSYCHIC, "Omega Psychic"},
+	{RACE_CELESTIALWARRIOR, "Celestial Warrior"},
+	{RACE_GALAXY, "Galaxy"},
+}
+
+// Line describes a monster as "LIGHT · Dragon · Level 8" and other cards
+// as "Spell Card" or "Trap Card".
+func (c *CardData) Line() string {
+	switch {
+	case c.IsSpell():
+		return "Spell Card"
+	case c.IsTrap():
+		return "Trap Card"
+	case !c.IsMonster():
+		return ""
+	}
+	var attr, race []string
+	for _, a := range AttributeNames {
+		if c.Attribute&a.Flag != 0 {
+			attr = append(attr, a.Name)
+		}
+	}
+	for _, r := range RaceNames {
+		if c.Race&r.Flag != 0 {
+			race = append(race, r.Name)
+		}
+	}
+	level := fmt.Sprintf("Level %d", c.Level)
+	switch {
+	case c.Type&TYPE_LINK != 0:
+		level = fmt.Sprintf("LINK-%d", c.Level)
+	case c.Type&TYPE_XYZ != 0:
+		level = fmt.Sprintf("Rank %d", c.Level)
+	}
+	return fmt.Sprintf("%s · %s · %s", strings.Join(attr, "/"), strings.Join(race, "/"), level)
+}
+
+// OT is the datas.ot bitmask describing which formats/regions a card was
+// released in (OCG, TCG, Anime, Rush, Speed, pre-release, ...).
+type OT uint32
+
+const (
+	OT_OCG        OT = 0x1
+	OT_TCG        OT = 0x2
+	OT_ANIME      OT = 0x4
+	OT_ILLEGAL    OT = 0x8
+	OT_VIDEO_GAME OT = 0x10
+	OT_CUSTOM     OT = 0x20
+	OT_SPEED      OT = 0x40
+	OT_PRERELEASE OT = 0x100
+	OT_RUSH       OT = 0x200
+	OT_LEGEND     OT = 0x400
+	OT_HIDDEN     OT = 0x1000
+)
+
+var otNames = []struct {
+	flag OT
+	name string
+}{
+	{OT_OCG, "OCG"},
+	{OT_TCG, "TCG"},
+	{OT_ANIME, "Anime"},
+	{OT_ILLEGAL, "Illegal"},
+	{OT_VIDEO_GAME, "VideoGame"},
+	{OT_CUSTOM, "Custom"},
+	{OT_SPEED, "Speed"},
+	{OT_PRERELEASE, "Prerelease"},
+	{OT_RUSH, "Rush"},
+	{OT_LEGEND, "Legend"},
+	{OT_HIDDEN, "Hidden"},
+}
+
+func (o OT) Has(flag OT) bool {
+	return o&flag == flag
+}
+
+// Names lists the name of every set bit, lowest bit first. Bits without a
+// name are reported as a single hex value at the end.
+func (o OT) Names() []string {
+	var names []string
+	rest := o
+	for _, n := range otNames {
+		if o.Has(n.flag) {
+			names = append(names, n.name)
+			rest &^= n.flag
+		}
+	}
+	if rest != 0 {
+		names = append(names, fmt.Sprintf("0x%x", uint32(rest)))
+	}
+	return names
+}
+
+func (o OT) String() string {
+	names := o.Names()
+	if len(names) == 0 {
+		return "None"
+	}
+	return strings.Join(names, "|")
+}
+
+// Category is the datas.category bitmask used by deck editors to tag what a
+// card's effects do (destroy, draw, search, special summon, ...).
+type Category uint64
+
+const (
+	CATEGORY_DESTROY        Category = 0x1
+	CATEGORY_RELEASE        Category = 0x2
+	CATEGORY_REMOVE         Category = 0x4
+	CATEGORY_TOHAND         Category = 0x8
+	CATEGORY_TODECK         Category = 0x10
+	CATEGORY_TOGRAVE        Category = 0x20
+	CATEGORY_DECKDES        Category = 0x40
+	CATEGORY_HANDES         Category = 0x80
+	CATEGORY_SUMMON         Category = 0x100
+	CATEGORY_SPECIAL_SUMMON Category = 0x200
+	CATEGORY_TOKEN          Category = 0x400
+	CATEGORY_FLIP           Category = 0x800
+	CATEGORY_POSITION       Category = 0x1000
+	CATEGORY_CONTROL        Category = 0x2000
+	CATEGORY_DISABLE        Category = 0x4000
+	CATEGORY_DISABLE_SUMMON Category = 0x8000
+	CATEGORY_DRAW           Category = 0x10000
+	CATEGORY_SEARCH         Category = 0x20000
+	CATEGORY_EQUIP          Category = 0x40000
+	CATEGORY_DAMAGE         Category = 0x80000
+	CATEGORY_RECOVER        Category = 0x100000
+	CATEGORY_ATKCHANGE      Category = 0x200000
+	CATEGORY_DEFCHANGE      Category = 0x400000
+	CATEGORY_COUNTER        Category = 0x800000
+	CATEGORY_COIN           Category = 0x1000000
+	CATEGORY_DICE           Category = 0x2000000
+	CATEGORY_LEAVE_GRAVE    Category = 0x4000000
+	CATEGORY_LVCHANGE       Category = 0x8000000
+	CATEGORY_NEGATE         Category = 0x10000000
+	CATEGORY_ANNOUNCE       Category = 0x20000000
+	CATEGORY_FUSION_SUMMON  Category = 0x40000000
+	CATEGORY_TOEXTRA        Category = 0x80000000
+)
+
+var categoryNames = []struct {
+	flag Category
+	name string
+}{
+	{CATEGORY_DESTROY, "Destroy"},
+	{CATEGORY_RELEASE, "Tribute"},
+	{CATEGORY_REMOVE, "Banish"},
+	{CATEGORY_TOHAND, "ToHand"},
+	{CATEGORY_TODECK, "ToDeck"},
+	{CATEGORY_TOGRAVE, "ToGrave"},
+	{CATEGORY_DECKDES, "Mill"},
+	{CATEGORY_HANDES, "Discard"},
+	{CATEGORY_SUMMON, "NormalSummon"},
+	{CATEGORY_SPECIAL_SUMMON, "SpecialSummon"},
+	{CATEGORY_TOKEN, "Token"},
+	{CATEGORY_FLIP, "Flip"},
+	{CATEGORY_POSITION, "ChangePosition"},
+	{CATEGORY_CONTROL, "Control"},
+	{CATEGORY_DISABLE, "NegateEffect"},
+	{CATEGORY_DISABLE_SUMMON, "NegateSummon"},
+	{CATEGORY_DRAW, "Draw"},
+	{CATEGORY_SEARCH, "Search"},
+	{CATEGORY_EQUIP, "Equip"},
+	{CATEGORY_DAMAGE, "Damage"},
+	{CATEGORY_RECOVER, "Recover"},
+	{CATEGORY_ATKCHANGE, "ATKChange"},
+	{CATEGORY_DEFCHANGE, "DEFChange"},
+	{CATEGORY_COUNTER, "Counter"},
+	{CATEGORY_COIN, "Coin"},
+	{CATEGORY_DICE, "Dice"},
+	{CATEGORY_LEAVE_GRAVE, "LeaveGrave"},
+	{CATEGORY_LVCHANGE, "LevelChange"},
+	{CATEGORY_NEGATE, "NegateActivation"},
+	{CATEGORY_ANNOUNCE, "Declare"},
+	{CATEGORY_FUSION_SUMMON, "FusionSummon"},
+	{CATEGORY_TOEXTRA, "ToExtra"},
+}
+
+func (c Category) Has(flag Category) bool {
+	return c&flag == flag
+}
+
+// Names lists the human-readable name of every set bit, lowest bit first.
+// Bits without a name are reported as a single hex value at the end.
+func (c Category) Names() []string {
+	var names []string
+	rest := c
+	for _, n := range categoryNames {
+		if c.Has(n.flag) {
+			names = append(names, n.name)
+			rest &^= n.flag
+		}
+	}
+	if rest != 0 {
+		names = append(names, fmt.Sprintf("0x%x", uint64(rest)))
+	}
+	return names
+}
+
+func (c Category) String() string {
+	names := c.Names()
+	if len(names) == 0 {
+		return "None"
+	}
+	return strings.Join(names, "|")
+}
+
+/*
+   Format helpers
+*/
+
+// notLegal marks releases that are not sanctioned regardless of region.
+const notLegal = OT_ILLEGAL | OT_PRERELEASE | OT_CUSTOM
+
+func (c *CardData) IsOCGLegal() bool {
+	return c.OT&OT_OCG != 0 && c.OT&notLegal == 0
+}
+
+func (c *CardData) IsTCGLegal() bool {
+	return c.OT&OT_TCG != 0 && c.OT&notLegal == 0
+}
+
+func (c *CardData) IsSpeed() bool {
+	return c.OT&OT_SPEED != 0
+}
+
+func (c *CardData) IsRush() bool {
+	return c.OT&OT_RUSH != 0
+}
+
+func (c *CardData) IsAnime() bool {
+	return c.OT&OT_ANIME != 0
+}
+
+func (c *CardData) IsCustom() bool {
+	return c.OT&OT_CUSTOM != 0
+}
+
+func (c *CardData) IsPrerelease() bool {
+	return c.OT&OT_PRERELEASE != 0
+}
+
+/*
+   Category helpers
+*/
+
+// HasCategory reports whether every bit of cat is set on the card.
+func (c *CardData) HasCategory(cat Category) bool {
+	return c.Category.Has(cat)
+}
+
+func (c *CardData) Destroys() bool {
+	return c.Category.Has(CATEGORY_DESTROY)
+}
+
+func (c *CardData) Draws() bool {
+	return c.Category.Has(CATEGORY_DRAW)
+}
+
+func (c *CardData) Searches() bool {
+	return c.Category.Has(CATEGORY_SEARCH)
+}
+
+func (c *CardData) SpecialSummons() bool {
+	return c.Category.Has(CATEGORY_SPECIAL_SUMMON)
+}
+
+func (c *CardData) Negates() bool {
+	return c.Category&(CATEGORY_NEGATE|CATEGORY_DISABLE|CATEGORY_DISABLE_SUMMON) != 0
+}
+
+func (c *CardData) Banishes() bool {
+	return c.Category.Has(CATEGORY_REMOVE)
+}
@@ -0,0 +1,61 @@
+package carddb
+
+// Layered reads card text from a translated cdb and everything else from
+// a base cdb, usually the English one.
+//
+// Translated cdbs often lag behind the base or leave fields empty, so
+// GetText falls back to the base per field: a card with a translated name
+// but no translated effect strings keeps the English strings. Card data
+// comes from the base; the translation is only asked when the base has no
+// row for the code.
+type Layered struct {
+	Text Source
+	Base Source
+}
+
+func NewLayered(text, base Source) *Layered {
+	return &Layered{Text: text, Base: base}
+}
+
+func (l *Layered) GetCard(code uint32) (*CardData, error) {
+	c, err := l.Base.GetCard(code)
+	if err != nil || c != nil {
+		return c, err
+	}
+	return l.Text.GetCard(code)
+}
+
+func (l *Layered) GetText(code uint32) (*CardText, error) {
+	base, err := l.Base.GetText(code)
+	if err != nil {
+		return nil, err
+	}
+	text, err := l.Text.GetText(code)
+	if err != nil {
+		return nil, err
+	}
+	if text == nil {
+		return base, nil
+	}
+	if base == nil {
+		return text, nil
+	}
+
+	out := *text
+	if out.Name == "" {
+		out.Name = base.Name
+	}
+	if out.Desc == "" {
+		out.Desc = base.Desc
+	}
+	for i, s := range out.Strings {
+		if s == "" {
+			out.Strings[i] = base.Strings[i]
+		}
+	}
+	return &out, nil
+}
+
+func (l *Layered) Canonical(code uint32) (uint32, error) {
+	return l.Base.Canonical(code)
+}
@@ -0,0 +1,216 @@
+package carddb
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// StringSlots is the number of strN columns in the classic texts table.
+const StringSlots = 16
+
+// CardText is a row of the texts table.
+type CardText struct {
+	Name    string
+	Desc    string
+	Strings [StringSlots]string
+}
+
+// CardRecord is everything stored about one card across datas and texts,
+// with the packed columns already decoded.
+type CardRecord struct {
+	CardData
+	Setcodes []uint16
+	Text     CardText
+}
+
+/*
+   Column packing (inverse of the decoding in scanCard)
+*/
+
+// PackLevel builds the datas.level column from level and pendulum scales.
+func PackLevel(level, lscale, rscale uint32) uint32 {
+	return (level & 0xFF) | (rscale&0xFF)<<16 | (lscale&0xFF)<<24
+}
+
+// PackDefense returns the datas.def column: link markers for link monsters,
+// DEF otherwise.
+func PackDefense(c *CardData) int32 {
+	if c.Type&TYPE_LINK != 0 {
+		return int32(c.LinkMarker)
+	}
+	return c.Defense
+}
+
+// PackSetcodes packs up to four 16-bit archetype codes into the
+// datas.setcode column, lowest slot first.
+func PackSetcodes(setcodes []uint16) (uint64, error) {
+	if len(setcodes) > 4 {
+		return 0, fmt.Errorf("at most 4 setcodes fit in a cdb row, got %d", len(setcodes))
+	}
+	var packed uint64
+	for i, sc := range setcodes {
+		packed |= uint64(sc) << (16 * i)
+	}
+	return packed, nil
+}
+
+// UnpackSetcodes is the inverse of PackSetcodes; empty slots are dropped.
+func UnpackSetcodes(packed uint64) []uint16 {
+	var setcodes []uint16
+	for i := 0; i < 4; i++ {
+		if sc := uint16(packed >> (16 * i)); sc != 0 {
+			setcodes = append(setcodes, sc)
+		}
+	}
+	return setcodes
+}
+
+/*
+   Reading full records
+*/
+
+// GetText reads the texts row of code, or nil if there is none.
+func (d *DB) GetText(code uint32) (*CardText, error) {
+	slots := d.stringSlots()
+	cols := []string{"name", "desc"}
+	for i := 1; i <= slots; i++ {
+		cols = append(cols, fmt.Sprintf("str%d", i))
+	}
+
+	row := d.db.QueryRow(`SELECT `+strings.Join(cols, ", ")+` FROM texts WHERE id = ?`, code)
+
+	raw := make([]sql.NullString, len(cols))
+	dest := make([]any, len(cols))
+	for i := range raw {
+		dest[i] = &raw[i]
+	}
+	err := row.Scan(dest...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("text query failed for code %d: %w", code, err)
+	}
+
+	t := &CardText{Name: raw[0].String, Desc: raw[1].String}
+	for i := 0; i < slots; i++ {
+		t.Strings[i] = raw[2+i].String
+	}
+	return t, nil
+}
+
+// GetRecord reads datas and texts of code, or nil if the card is missing.
+func (d *DB) GetRecord(code uint32) (*CardRecord, error) {
+	card, err := d.GetCard(code)
+	if err != nil || card == nil {
+		return nil, err
+	}
+
+	// setcode is stored signed; a fourth slot of 0x8000 or more is negative.
+	var setcode int64
+	if err := d.db.QueryRow(`SELECT setcode FROM datas WHERE id = ?`, code).Scan(&setcode); err != nil {
+		return nil, fmt.Errorf("setcode query failed for code %d: %w", code, err)
+	}
+
+	rec := &CardRecord{CardData: *card, Setcodes: UnpackSetcodes(uint64(setcode))}
+	text, err := d.GetText(code)
+	if err != nil {
+		return nil, err
+	}
+	if text != nil {
+		rec.Text = *text
+	}
+	return rec, nil
+}
+
+// AllRecords reads every card with its texts in ascending code order.
+// Cards without a texts row get an empty CardText.
+func (d *DB) AllRecords() ([]*CardRecord, error) {
+	slots := d.stringSlots()
+	textCols := []string{"t.name", "t.desc"}
+	for i := 1; i <= slots; i++ {
+		textCols = append(textCols, fmt.Sprintf("t.str%d", i))
+	}
+
+	rows, err := d.db.Query(`
+		SELECT d.id, d.alias, d.type, d.level,
+		       d.attribute, d.race,
+		       d.atk, d.def,
+		       d.ot, d.category, d.setcode, ` + strings.Join(textCols, ", ") + `
+		FROM datas d LEFT JOIN texts t ON t.id = d.id
+		ORDER BY d.id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query records: %w", err)
+	}
+	defer rows.Close()
+
+	var recs []*CardRecord
+	for rows.Next() {
+		var setcode int64
+		raw := make([]sql.NullString, len(textCols))
+		extra := []any{&setcode}
+		for i := range raw {
+			extra = append(extra, &raw[i])
+		}
+
+		card, err := scanCard(extraColumns{rows, extra})
+		if err != nil {
+			return nil, fmt.Errorf("failed scanning record row: %w", err)
+		}
+
+		rec := &CardRecord{
+			CardData: *card,
+			Setcodes: UnpackSetcodes(uint64(setcode)),
+			Text:     CardText{Name: raw[0].String, Desc: raw[1].String},
+		}
+		for i := 0; i < slots; i++ {
+			rec.Text.Strings[i] = raw[2+i].String
+		}
+		recs = append(recs, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating record rows: %w", err)
+	}
+	return recs, nil
+}
+
+// extraColumns lets scanCard read the leading card columns of a wider row,
+// scanning the remaining columns into extra.
+type extraColumns struct {
+	row   rowScanner
+	extra []any
+}
+
+func (e extraColumns) Scan(dest ...any) error {
+	return e.row.Scan(append(dest, e.extra...)...)
+}
+
+// Codes lists every card code in the database in ascending order.
+func (d *DB) Codes() ([]uint32, error) {
+	rows, err := d.db.Query(`SELECT id FROM datas ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query codes: %w", err)
+	}
+	defer rows.Close()
+
+	var codes []uint32
+	for rows.Next() {
+		var c uint32
+		if err := rows.Scan(&c); err != nil {
+			return nil, fmt.Errorf("failed scanning code row: %w", err)
+		}
+		codes = append(codes, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating code rows: %w", err)
+	}
+	return codes, nil
+}
+
+func (d *DB) stringSlots() int {
+	if d.schema == nil || d.schema.StringSlots > StringSlots {
+		return StringSlots
+	}
+	return d.schema.StringSlots
+}
@@ -0,0 +1,166 @@
+package carddb
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidSchema is returned (wrapped) by Open when the file is not a
+// usable card database.
+var ErrInvalidSchema = errors.New("invalid card database schema")
+
+// SchemaVariant identifies the flavour of cdb that was opened.
+type SchemaVariant int
+
+const (
+	SchemaUnknown SchemaVariant = iota
+	// SchemaClassic is the original YGOPro layout: datas with 11 columns and
+	// texts with name, desc and str1–str16.
+	SchemaClassic
+	// SchemaExtended is a classic layout carrying additional columns, as
+	// written by EDOPro-era tooling (extra string slots, script or support
+	// columns, ...). Extra columns are ignored when reading cards.
+	SchemaExtended
+)
+
+func (v SchemaVariant) String() string {
+	switch v {
+	case SchemaClassic:
+		return "classic"
+	case SchemaExtended:
+		return "extended"
+	default:
+		return "unknown"
+	}
+}
+
+// requiredColumns lists every column the readers in this package rely on.
+var requiredColumns = map[string][]string{
+	"datas": {"id", "ot", "alias", "setcode", "type", "atk", "def", "level", "race", "attribute", "category"},
+	"texts": {"id", "name", "desc"},
+}
+
+// SchemaInfo describes the layout of an opened card database.
+type SchemaInfo struct {
+	Variant SchemaVariant
+	// Columns maps table name to its columns in declaration order.
+	Columns map[string][]string
+	// ExtraColumns lists, per table, columns beyond the classic layout.
+	ExtraColumns map[string][]string
+	// StringSlots is the number of strN columns in texts.
+	StringSlots int
+	CardCount   int
+}
+
+func (s *SchemaInfo) HasColumn(table, column string) bool {
+	for _, c := range s.Columns[table] {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
+
+// Schema returns the structure detected when the database was opened.
+func (d *DB) Schema() *SchemaInfo {
+	return d.schema
+}
+
+func (d *DB) inspectSchema() (*SchemaInfo, error) {
+	info := &SchemaInfo{
+		Columns:      make(map[string][]string),
+		ExtraColumns: make(map[string][]string),
+	}
+
+	for table := range requiredColumns {
+		cols, err := d.tableColumns(table)
+		if err != nil {
+			return nil, fmt.Errorf("%w: cannot read table layout, is this a sqlite .cdb file? (%w)", ErrInvalidSchema, err)
+		}
+		if len(cols) > 0 {
+			info.Columns[table] = cols
+		}
+	}
+
+	var problems []string
+	tables := make([]string, 0, len(requiredColumns))
+	for table := range requiredColumns {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	for _, table := range tables {
+		if _, ok := info.Columns[table]; !ok {
+			problems = append(problems, fmt.Sprintf("missing table %q", table))
+			continue
+		}
+		for _, col := range requiredColumns[table] {
+			if !info.HasColumn(table, col) {
+				problems = append(problems, fmt.Sprintf("table %q is missing column %q", table, col))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSchema, strings.Join(problems, "; "))
+	}
+
+	for _, col := range info.Columns["texts"] {
+		var n int
+		if _, err := fmt.Sscanf(col, "str%d", &n); err == nil && fmt.Sprintf("str%d", n) == col {
+			info.StringSlots++
+		}
+	}
+
+	info.Variant = SchemaClassic
+	for _, table := range tables {
+		for _, col := range info.Columns[table] {
+			if !isClassicColumn(table, col) {
+				info.ExtraColumns[table] = append(info.ExtraColumns[table], col)
+				info.Variant = SchemaExtended
+			}
+		}
+	}
+
+	if err := d.db.QueryRow(`SELECT COUNT(*) FROM datas`).Scan(&info.CardCount); err != nil {
+		return nil, fmt.Errorf("failed to count cards: %w", err)
+	}
+
+	return info, nil
+}
+
+func isClassicColumn(table, col string) bool {
+	for _, c := range requiredColumns[table] {
+		if c == col {
+			return true
+		}
+	}
+	if table == "texts" {
+		var n int
+		if _, err := fmt.Sscanf(col, "str%d", &n); err == nil && n >= 1 && n <= 16 && fmt.Sprintf("str%d", n) == col {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *DB) tableColumns(table string) ([]string, error) {
+	rows, err := d.db.Query(`SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var cols []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed scanning column of %s: %w", table, err)
+		}
+		cols = append(cols, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating columns of %s: %w", table, err)
+	}
+	return cols, nil
+}
@@ -0,0 +1,228 @@
+package carddb
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// classicSchema is the table layout written by Create.
+var classicSchema = []string{
+	`CREATE TABLE datas(
+		id integer primary key,
+		ot integer, alias integer, setcode integer, type integer,
+		atk integer, def integer, level integer, race integer,
+		attribute integer, category integer)`,
+	`CREATE TABLE texts(
+		id integer primary key,
+		name text, desc text,
+		str1 text, str2 text, str3 text, str4 text,
+		str5 text, str6 text, str7 text, str8 text,
+		str9 text, str10 text, str11 text, str12 text,
+		str13 text, str14 text, str15 text, str16 text)`,
+}
+
+// Create makes a new, empty cdb with the classic schema and opens it. It
+// refuses to overwrite an existing file.
+func Create(path string) (*DB, error) {
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("failed to create card db: %s already exists", path)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to create card db: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create card db: %w", err)
+	}
+	for _, stmt := range classicSchema {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			os.Remove(path)
+			return nil, fmt.Errorf("failed to create card db schema: %w", err)
+		}
+	}
+	db.Close()
+
+	return Open(path)
+}
+
+// Writer inserts, updates and deletes card records, packing level, scales,
+// link markers and setcodes the way GetCard expects to decode them.
+type Writer struct {
+	db     *DB
+	caches []*Cache
+}
+
+func NewWriter(db *DB) *Writer {
+	return &Writer{db: db}
+}
+
+// AttachCache makes the writer invalidate c for every code it touches, so
+// readers going through the cache see the new rows.
+func (w *Writer) AttachCache(c *Cache) {
+	w.caches = append(w.caches, c)
+}
+
+// Put inserts rec or replaces the existing card with the same code. Its
+// datas and texts rows are written together or not at all.
+func (w *Writer) Put(rec *CardRecord) error {
+	slots := w.db.stringSlots()
+	err := w.inTx("put", func(tx *sql.Tx) error {
+		return putRecord(tx, rec, slots)
+	})
+	if err != nil {
+		return err
+	}
+	w.invalidate(rec.Code)
+	return nil
+}
+
+// Delete removes code from both tables. Deleting a missing card is a no-op.
+func (w *Writer) Delete(code uint32) error {
+	err := w.inTx("delete", func(tx *sql.Tx) error {
+		return deleteRecord(tx, code)
+	})
+	if err != nil {
+		return err
+	}
+	w.invalidate(code)
+	return nil
+}
+
+// Import writes all records in a single transaction: either every record is
+// stored or none is.
+func (w *Writer) Import(recs []*CardRecord) error {
+	slots := w.db.stringSlots()
+	err := w.inTx("import", func(tx *sql.Tx) error {
+		for _, rec := range recs {
+			if err := putRecord(tx, rec, slots); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, rec := range recs {
+		w.invalidate(rec.Code)
+	}
+	return nil
+}
+
+// inTx runs fn in a transaction, committing if it succeeds and rolling back
+// if it fails.
+func (w *Writer) inTx(op string, fn func(tx *sql.Tx) error) error {
+	tx, err := w.db.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin %s: %w", op, err)
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit %s: %w", op, err)
+	}
+	return nil
+}
+
+// Export copies the given cards into a fresh standalone cdb at path.
+// Codes missing from the source database are reported as an error and
+// nothing is written.
+func (w *Writer) Export(path string, codes []uint32) error {
+	return w.db.Export(path, codes)
+}
+
+// Export copies the given cards into a fresh standalone cdb at path. A
+// failed export leaves no file behind.
+func (d *DB) Export(path string, codes []uint32) error {
+	recs := make([]*CardRecord, 0, len(codes))
+	var missing []string
+	for _, code := range codes {
+		rec, err := d.GetRecord(code)
+		if err != nil {
+			return err
+		}
+		if rec == nil {
+			missing = append(missing, fmt.Sprint(code))
+			continue
+		}
+		recs = append(recs, rec)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("cannot export unknown cards: %s", strings.Join(missing, ", "))
+	}
+
+	out, err := Create(path)
+	if err != nil {
+		return err
+	}
+	if err := NewWriter(out).Import(recs); err != nil {
+		out.Close()
+		os.Remove(path)
+		return err
+	}
+	out.Close()
+	return nil
+}
+
+func (w *Writer) invalidate(code uint32) {
+	for _, c := range w.caches {
+		c.Invalidate(code)
+	}
+}
+
+func putRecord(tx *sql.Tx, rec *CardRecord, slots int) error {
+	setcode, err := PackSetcodes(rec.Setcodes)
+	if err != nil {
+		return fmt.Errorf("card %d: %w", rec.Code, err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT OR REPLACE INTO datas
+			(id, ot, alias, setcode, type, atk, def, level, race, attribute, category)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		rec.Code,
+		uint32(rec.OT),
+		rec.Alias,
+		int64(setcode),
+		rec.Type,
+		rec.Attack,
+		PackDefense(&rec.CardData),
+		PackLevel(rec.Level, rec.Lscale, rec.Rscale),
+		int64(rec.Race),
+		rec.Attribute,
+		int64(rec.Category),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to write datas for %d: %w", rec.Code, err)
+	}
+
+	cols := []string{"id", "name", "desc"}
+	args := []any{rec.Code, rec.Text.Name, rec.Text.Desc}
+	for i := 0; i < slots; i++ {
+		cols = append(cols, fmt.Sprintf("str%d", i+1))
+		args = append(args, rec.Text.Strings[i])
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(cols)), ", ")
+
+	_, err = tx.Exec(`INSERT OR REPLACE INTO texts (`+strings.Join(cols, ", ")+`) VALUES (`+placeholders+`)`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to write texts for %d: %w", rec.Code, err)
+	}
+	return nil
+}
+
+func deleteRecord(tx *sql.Tx, code uint32) error {
+	if _, err := tx.Exec(`DELETE FROM datas WHERE id = ?`, code); err != nil {
+		return fmt.Errorf("failed to delete datas for %d: %w", code, err)
+	}
+	if _, err := tx.Exec(`DELETE FROM texts WHERE id = ?`, code); err != nil {
+		return fmt.Errorf("failed to delete texts for %d: %w", code, err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package deck
+
+import (
+	"fmt"
+
+	"github.com/spb8026/ygo-visualizer/carddb"
+)
+
+// Location and position values used when placing a deck into a duel. They
+// mirror the core's LOCATION_* / POS_* values (see bridge.LOC_*).
+const (
+	locDeck     = 0x1
+	locExtra    = 0x40
+	posFacedown = 0xa
+)
+
+const (
+	SectionMain  = "main"
+	SectionExtra = "extra"
+	SectionSide  = "side"
+)
+
+// Deck is a main/extra/side card list in file order.
+type Deck struct {
+	Main  []uint32
+	Extra []uint32
+	Side  []uint32
+
+	// CreatedBy is the value of a "#created by ..." line, if any.
+	CreatedBy string
+	// Comments holds any other "#" lines found before #main, in order.
+	Comments []string
+}
+
+// Clone returns a deep copy of d.
+func (d *Deck) Clone() *Deck {
+	return &Deck{
+		Main:      append([]uint32(nil), d.Main...),
+		Extra:     append([]uint32(nil), d.Extra...),
+		Side:      append([]uint32(nil), d.Side...),
+		CreatedBy: d.CreatedBy,
+		Comments:  append([]string(nil), d.Comments...),
+	}
+}
+
+// Section returns the slice for "main", "extra" or "side".
+func (d *Deck) Section(name string) []uint32 {
+	switch name {
+	case SectionMain:
+		return d.Main
+	case SectionExtra:
+		return d.Extra
+	case SectionSide:
+		return d.Side
+	}
+	return nil
+}
+
+// CardLookup is satisfied by *carddb.DB and *carddb.Cache.
+type CardLookup interface {
+	GetCard(code uint32) (*carddb.CardData, error)
+}
+
+// UnknownCard is a deck entry whose code is missing from the card database.
+type UnknownCard struct {
+	Section string
+	Index   int
+	Code    uint32
+}
+
+func (u UnknownCard) String() string {
+	return fmt.Sprintf("%s #%d: unknown card %d", u.Section, u.Index+1, u.Code)
+}
+
+// Unknown reports every entry whose code is not in db.
+func (d *Deck) Unknown(db CardLookup) ([]UnknownCard, error) {
+	var unknown []UnknownCard
+	for _, section := range []string{SectionMain, SectionExtra, SectionSide} {
+		for i, code := range d.Section(section) {
+			card, err := db.GetCard(code)
+			if err != nil {
+				return nil, err
+			}
+			if card == nil {
+				unknown = append(unknown, UnknownCard{Section: section, Index: i, Code: code})
+			}
+		}
+	}
+	return unknown, nil
+}
+
+// CardAdder is the part of *bridge.Duel needed to place cards.
+type CardAdder interface {
+	AddCard(team, duelist uint8, code uint32, con uint8, loc, seq, pos uint32)
+}
+
+// AddTo places the main deck and extra deck of d for player into a duel
+// that has not started yet. The side deck is not used in a duel.
+func (d *Deck) AddTo(duel CardAdder, player uint8) {
+	for i, code := range d.Main {
+		duel.AddCard(player, 0, code, player, locDeck, uint32(i), posFacedown)
+	}
+	for i, code := range d.Extra {
+		duel.AddCard(player, 0, code, player, locExtra, uint32(i), posFacedown)
+	}
+}
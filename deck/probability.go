@@ -0,0 +1,211 @@
+package deck
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+const (
+	// HandFirst is the opening hand size of the player going first.
+	HandFirst = 5
+	// HandSecond is the opening hand size (after the first draw) going second.
+	HandSecond = 6
+)
+
+// Roles groups main deck cards under user-defined names such as "starter"
+// or "extender". A code may belong to several roles; every copy of a code
+// in the main deck counts toward each of its roles.
+type Roles map[string][]uint32
+
+// Condition requires the number of cards of Role in hand to be within
+// [Min, Max]. A negative Max means no upper bound.
+type Condition struct {
+	Role string
+	Min  int
+	Max  int
+}
+
+func AtLeast(role string, n int) Condition {
+	return Condition{Role: role, Min: n, Max: -1}
+}
+
+func Exactly(role string, n int) Condition {
+	return Condition{Role: role, Min: n, Max: n}
+}
+
+func None(role string) Condition {
+	return Condition{Role: role, Min: 0, Max: 0}
+}
+
+func (c Condition) holds(n int) bool {
+	return n >= c.Min && (c.Max < 0 || n <= c.Max)
+}
+
+// roleClass is a set of main deck copies that share exactly the same roles.
+type roleClass struct {
+	roles map[string]bool
+	count int
+}
+
+// classify splits the main deck into classes by role membership. The last
+// class holds cards with no role (possibly empty).
+func classify(d *Deck, roles Roles) []roleClass {
+	membership := make(map[uint32][]string)
+	for role, codes := range roles {
+		for _, code := range codes {
+			membership[code] = append(membership[code], role)
+		}
+	}
+
+	byKey := make(map[string]*roleClass)
+	var keys []string
+	for _, code := range d.Main {
+		rs := append([]string(nil), membership[code]...)
+		sort.Strings(rs)
+		key := fmt.Sprint(rs)
+		c, ok := byKey[key]
+		if !ok {
+			c = &roleClass{roles: make(map[string]bool)}
+			for _, r := range rs {
+				c.roles[r] = true
+			}
+			byKey[key] = c
+			keys = append(keys, key)
+		}
+		c.count++
+	}
+	sort.Strings(keys)
+
+	classes := make([]roleClass, 0, len(keys))
+	for _, k := range keys {
+		classes = append(classes, *byKey[k])
+	}
+	return classes
+}
+
+// OpeningProbability returns the exact probability that a handSize-card
+// opening hand from the main deck satisfies every condition. It sums the
+// multivariate hypergeometric distribution over all ways of drawing from
+// each role class.
+func OpeningProbability(d *Deck, roles Roles, handSize int, conds ...Condition) (float64, error) {
+	if err := checkHand(d, roles, handSize, conds); err != nil {
+		return 0, err
+	}
+
+	classes := classify(d, roles)
+	total := binomial(len(d.Main), handSize)
+
+	drawn := make([]int, len(classes))
+	var sum float64
+	var walk func(i, left int, ways float64)
+	walk = func(i, left int, ways float64) {
+		if i == len(classes) {
+			if left == 0 && satisfied(classes, drawn, conds) {
+				sum += ways
+			}
+			return
+		}
+		for k := 0; k <= classes[i].count && k <= left; k++ {
+			drawn[i] = k
+			walk(i+1, left-k, ways*binomial(classes[i].count, k))
+		}
+		drawn[i] = 0
+	}
+	walk(0, handSize, 1)
+
+	return sum / total, nil
+}
+
+func satisfied(classes []roleClass, drawn []int, conds []Condition) bool {
+	for _, c := range conds {
+		n := 0
+		for i, cl := range classes {
+			if cl.roles[c.Role] {
+				n += drawn[i]
+			}
+		}
+		if !c.holds(n) {
+			return false
+		}
+	}
+	return true
+}
+
+// MonteCarlo estimates the same probability as OpeningProbability by
+// shuffling the main deck trials times with the duel engine's generator
+// seeded from seed, and counting hands that satisfy every condition.
+func MonteCarlo(d *Deck, roles Roles, handSize, trials int, seed [4]uint64, conds ...Condition) (float64, error) {
+	if err := checkHand(d, roles, handSize, conds); err != nil {
+		return 0, err
+	}
+	if trials <= 0 {
+		return 0, fmt.Errorf("trials must be positive, got %d", trials)
+	}
+
+	membership := make(map[uint32]map[string]bool)
+	for role, codes := range roles {
+		for _, code := range codes {
+			if membership[code] == nil {
+				membership[code] = make(map[string]bool)
+			}
+			membership[code][role] = true
+		}
+	}
+
+	rng := NewRand(seed)
+	cards := make([]uint32, len(d.Main))
+	hits := 0
+	for t := 0; t < trials; t++ {
+		copy(cards, d.Main)
+		hand := rng.Deal(cards, handSize)
+
+		ok := true
+		for _, c := range conds {
+			n := 0
+			for _, code := range hand {
+				if membership[code][c.Role] {
+					n++
+				}
+			}
+			if !c.holds(n) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			hits++
+		}
+	}
+	return float64(hits) / float64(trials), nil
+}
+
+func checkHand(d *Deck, roles Roles, handSize int, conds []Condition) error {
+	if handSize <= 0 || handSize > len(d.Main) {
+		return fmt.Errorf("hand size %d out of range for a %d-card main deck", handSize, len(d.Main))
+	}
+	for _, c := range conds {
+		if _, ok := roles[c.Role]; !ok {
+			return fmt.Errorf("condition uses undefined role %q", c.Role)
+		}
+		if c.Max >= 0 && c.Max < c.Min {
+			return fmt.Errorf("condition on %q has max %d below min %d", c.Role, c.Max, c.Min)
+		}
+	}
+	return nil
+}
+
+// binomial returns C(n, k) as a float64; exact for every deck-sized input.
+func binomial(n, k int) float64 {
+	if k < 0 || k > n {
+		return 0
+	}
+	if k > n-k {
+		k = n - k
+	}
+	r := 1.0
+	for i := 1; i <= k; i++ {
+		r = r * float64(n-k+i) / float64(i)
+	}
+	return math.Round(r)
+}
@@ -0,0 +1,104 @@
+package deck
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// roleDeck is a 40-card deck: 3 copies each of two starters, 2 of a card
+// that is both a starter and an extender, 3 extenders, 3 bricks and 26
+// other cards.
+func roleDeck() (*Deck, Roles) {
+	d := &Deck{}
+	add := func(code uint32, n int) {
+		for i := 0; i < n; i++ {
+			d.Main = append(d.Main, code)
+		}
+	}
+	add(1, 3)
+	add(2, 3)
+	add(3, 2)
+	add(4, 3)
+	add(5, 3)
+	for code := uint32(100); len(d.Main) < 40; code++ {
+		add(code, 1)
+	}
+	roles := Roles{
+		"starter":  {1, 2, 3},
+		"extender": {3, 4},
+		"brick":    {5},
+	}
+	return d, roles
+}
+
+func TestOpeningProbabilityExact(t *testing.T) {
+	d, roles := roleDeck()
+	// 1 - C(32,5)/C(40,5): at least one of the 8 starters.
+	want := 1 - 201376.0/658008.0
+	got, err := OpeningProbability(d, roles, HandFirst, AtLeast("starter", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(got-want) > 1e-12 {
+		t.Errorf("OpeningProbability = %v, want %v", got, want)
+	}
+}
+
+func TestOpeningProbabilityMatchesMonteCarlo(t *testing.T) {
+	// trials and tolerance keep the check at about ten standard errors.
+	const trials = 100000
+	const tolerance = 0.015
+
+	d, roles := roleDeck()
+	cases := []struct {
+		name  string
+		hand  int
+		conds []Condition
+	}{
+		{"starter", HandFirst, []Condition{AtLeast("starter", 1)}},
+		{"starter and extender", HandFirst, []Condition{AtLeast("starter", 1), AtLeast("extender", 1)}},
+		{"no bricks", HandSecond, []Condition{AtLeast("starter", 1), None("brick")}},
+		{"exactly two starters", HandSecond, []Condition{Exactly("starter", 2)}},
+	}
+	for i, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			exact, err := OpeningProbability(d, roles, c.hand, c.conds...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			seed := [4]uint64{uint64(i + 1), 2, 3, 4}
+			estimate, err := MonteCarlo(d, roles, c.hand, trials, seed, c.conds...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(exact-estimate) > tolerance {
+				t.Errorf("exact %.4f, Monte Carlo %.4f", exact, estimate)
+			}
+		})
+	}
+}
+
+func TestMonteCarloDealsFromTheTop(t *testing.T) {
+	// The CLIs' duel seed; the core draws from the end of the deck list.
+	seed := [4]uint64{12345, 0, 0, 0}
+	d := &Deck{}
+	for code := uint32(1); code <= 40; code++ {
+		d.Main = append(d.Main, code)
+	}
+	want := []uint32{40, 18, 7, 22, 1}
+
+	cards := append([]uint32(nil), d.Main...)
+	if got := NewRand(seed).Deal(cards, HandFirst); !reflect.DeepEqual(got, want) {
+		t.Errorf("Deal = %v, want %v", got, want)
+	}
+
+	roles := Roles{"dealt": want}
+	got, err := MonteCarlo(d, roles, HandFirst, 1, seed, AtLeast("dealt", len(want)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 1 {
+		t.Errorf("MonteCarlo's first hand is not %v", want)
+	}
+}
@@ -0,0 +1,61 @@
+package deck
+
+import "math/bits"
+
+// Rand is the xoshiro256** generator the core seeds from
+// DuelOptions.Seed, so simulated draws use the same random stream as a
+// duel started with that seed.
+type Rand struct {
+	s [4]uint64
+}
+
+func NewRand(seed [4]uint64) *Rand {
+	return &Rand{s: seed}
+}
+
+// Uint64 returns the next raw value.
+func (r *Rand) Uint64() uint64 {
+	result := bits.RotateLeft64(r.s[1]*5, 7) * 9
+	t := r.s[1] << 17
+
+	r.s[2] ^= r.s[0]
+	r.s[3] ^= r.s[1]
+	r.s[1] ^= r.s[2]
+	r.s[0] ^= r.s[3]
+
+	r.s[2] ^= t
+	r.s[3] = bits.RotateLeft64(r.s[3], 45)
+
+	return result
+}
+
+// Intn returns a uniform integer in [lo, hi], rejecting the biased low
+// range the same way the core's get_next_integer does.
+func (r *Rand) Intn(lo, hi int) int {
+	span := uint64(hi - lo + 1)
+	limit := ^uint64(0) % span
+	var n uint64
+	for {
+		n = r.Uint64()
+		if n > limit {
+			break
+		}
+	}
+	return int(n%span) + lo
+}
+
+// Shuffle permutes codes in place with the core's deck shuffle: a
+// Fisher–Yates pass from the last card down.
+func (r *Rand) Shuffle(codes []uint32) {
+	for i := len(codes) - 1; i > 0; i-- {
+		j := r.Intn(0, i)
+		codes[i], codes[j] = codes[j], codes[i]
+	}
+}
+
+// Deal shuffles codes in place and returns the n cards drawn from it. The
+// core draws from the end of its deck list, so they are the last n.
+func (r *Rand) Deal(codes []uint32, n int) []uint32 {
+	r.Shuffle(codes)
+	return codes[len(codes)-n:]
+}
@@ -0,0 +1,51 @@
+package deck
+
+import (
+	"fmt"
+	"sort"
+)
+
+// CheckSide reports whether sided is a legal side-decking of registered:
+// the same cards overall, only moved between sections, with every section
+// size still within the bounds of format.
+func CheckSide(registered, sided *Deck, format Format) error {
+	sizes := format.Sizes()
+	if n := len(sided.Main); n < sizes.MainMin || n > sizes.MainMax {
+		return fmt.Errorf("main deck has %d cards after siding, must be %d–%d", n, sizes.MainMin, sizes.MainMax)
+	}
+	if n := len(sided.Extra); n > sizes.ExtraMax {
+		return fmt.Errorf("extra deck has %d cards after siding, at most %d allowed", n, sizes.ExtraMax)
+	}
+	if n := len(sided.Side); n > sizes.SideMax {
+		return fmt.Errorf("side deck has %d cards after siding, at most %d allowed", n, sizes.SideMax)
+	}
+
+	counts := make(map[uint32]int)
+	for _, section := range []string{SectionMain, SectionExtra, SectionSide} {
+		for _, code := range registered.Section(section) {
+			counts[code]++
+		}
+		for _, code := range sided.Section(section) {
+			counts[code]--
+		}
+	}
+
+	var added, removed []uint32
+	for code, n := range counts {
+		switch {
+		case n < 0:
+			added = append(added, code)
+		case n > 0:
+			removed = append(removed, code)
+		}
+	}
+	if len(added) > 0 {
+		sort.Slice(added, func(i, j int) bool { return added[i] < added[j] })
+		return fmt.Errorf("siding added cards not in the registered deck: %v", added)
+	}
+	if len(removed) > 0 {
+		sort.Slice(removed, func(i, j int) bool { return removed[i] < removed[j] })
+		return fmt.Errorf("siding removed cards from the registered deck: %v", removed)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package deck
+
+import (
+	"fmt"
+
+	"github.com/spb8026/ygo-visualizer/carddb"
+)
+
+// Stats summarises the composition of a deck.
+type Stats struct {
+	Main, Extra, Side int
+
+	// Main deck card kinds.
+	Monsters, Spells, Traps int
+	// Extra deck monsters by summon type.
+	Fusion, Synchro, Xyz, Link int
+
+	// Levels counts main deck monsters by level.
+	Levels map[uint32]int
+	// Roles counts main deck copies per role (when roles are given).
+	Roles map[string]int
+	// Unknown counts entries missing from the card database.
+	Unknown int
+}
+
+// Analyze computes Stats for d. roles may be nil.
+func Analyze(d *Deck, db carddb.Source, roles Roles) (*Stats, error) {
+	s := &Stats{
+		Main:   len(d.Main),
+		Extra:  len(d.Extra),
+		Side:   len(d.Side),
+		Levels: make(map[uint32]int),
+		Roles:  make(map[string]int),
+	}
+
+	for _, code := range d.Main {
+		card, err := db.GetCard(code)
+		if err != nil {
+			return nil, err
+		}
+		if card == nil {
+			s.Unknown++
+			continue
+		}
+		switch {
+		case card.IsMonster():
+			s.Monsters++
+			s.Levels[card.Level]++
+		case card.IsSpell():
+			s.Spells++
+		case card.IsTrap():
+			s.Traps++
+		}
+	}
+
+	for _, code := range d.Extra {
+		card, err := db.GetCard(code)
+		if err != nil {
+			return nil, err
+		}
+		if card == nil {
+			s.Unknown++
+			continue
+		}
+		switch {
+		case card.Type&carddb.TYPE_LINK != 0:
+			s.Link++
+		case card.Type&carddb.TYPE_XYZ != 0:
+			s.Xyz++
+		case card.Type&carddb.TYPE_SYNCHRO != 0:
+			s.Synchro++
+		case card.Type&carddb.TYPE_FUSION != 0:
+			s.Fusion++
+		}
+	}
+
+	for role, codes := range roles {
+		in := make(map[uint32]bool, len(codes))
+		for _, c := range codes {
+			in[c] = true
+		}
+		for _, code := range d.Main {
+			if in[code] {
+				s.Roles[role]++
+			}
+		}
+	}
+
+	return s, nil
+}
+
+func (s *Stats) String() string {
+	return fmt.Sprintf("main %d (%d monsters, %d spells, %d traps), extra %d (%d fusion, %d synchro, %d xyz, %d link), side %d",
+		s.Main, s.Monsters, s.Spells, s.Traps,
+		s.Extra, s.Fusion, s.Synchro, s.Xyz, s.Link,
+		s.Side)
+}
@@ -0,0 +1,248 @@
+package deck
+
+import (
+	"fmt"
+
+	"github.com/spb8026/ygo-visualizer/banlist"
+	"github.com/spb8026/ygo-visualizer/carddb"
+)
+
+// Format selects deck size rules and which card pool is legal.
+type Format int
+
+const (
+	// FormatAny applies the Advanced deck size rules without restricting
+	// the card pool.
+	FormatAny Format = iota
+	FormatTCG
+	FormatOCG
+	FormatSpeed
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatTCG:
+		return "TCG"
+	case FormatOCG:
+		return "OCG"
+	case FormatSpeed:
+		return "Speed"
+	default:
+		return "Any"
+	}
+}
+
+// SizeRules are the inclusive section size bounds of a format.
+type SizeRules struct {
+	MainMin, MainMax int
+	ExtraMax         int
+	SideMax          int
+	Copies           int
+}
+
+func (f Format) Sizes() SizeRules {
+	if f == FormatSpeed {
+		return SizeRules{MainMin: 20, MainMax: 30, ExtraMax: 5, SideMax: 5, Copies: 3}
+	}
+	return SizeRules{MainMin: 40, MainMax: 60, ExtraMax: 15, SideMax: 15, Copies: 3}
+}
+
+func (f Format) allows(c *carddb.CardData) bool {
+	switch f {
+	case FormatTCG:
+		return c.IsTCGLegal()
+	case FormatOCG:
+		return c.IsOCGLegal()
+	case FormatSpeed:
+		return c.IsSpeed()
+	default:
+		return true
+	}
+}
+
+// Rule identifies which check produced a Violation.
+type Rule string
+
+const (
+	RuleMainSize     Rule = "main-size"
+	RuleExtraSize    Rule = "extra-size"
+	RuleSideSize     Rule = "side-size"
+	RuleUnknownCard  Rule = "unknown-card"
+	RuleWrongSection Rule = "wrong-section"
+	RuleCopies       Rule = "copies"
+	RuleBanlist      Rule = "banlist"
+	RuleFormat       Rule = "format"
+)
+
+// Violation is one broken deck rule. Code and Name are zero/empty for
+// section size violations.
+type Violation struct {
+	Rule    Rule
+	Section string
+	Code    uint32
+	Name    string
+	Message string
+}
+
+func (v Violation) String() string {
+	return v.Message
+}
+
+// Validate checks d against the deck construction rules of format and, if
+// list is non-nil, the banlist. It returns every violation found; the error
+// is only set when the card database fails.
+//
+// Copy and banlist limits are counted per canonical card across main,
+// extra and side, so alternate artworks share one limit. A card whose name
+// is only treated as another's, like Cyber Harpie Lady, counts on its own.
+func Validate(d *Deck, db carddb.Source, list *banlist.List, format Format) ([]Violation, error) {
+	v := &validator{db: db, names: make(map[uint32]string)}
+	sizes := format.Sizes()
+
+	if n := len(d.Main); n < sizes.MainMin || n > sizes.MainMax {
+		v.add(Violation{Rule: RuleMainSize, Section: SectionMain,
+			Message: fmt.Sprintf("main deck has %d cards, must be %d–%d", n, sizes.MainMin, sizes.MainMax)})
+	}
+	if n := len(d.Extra); n > sizes.ExtraMax {
+		v.add(Violation{Rule: RuleExtraSize, Section: SectionExtra,
+			Message: fmt.Sprintf("extra deck has %d cards, at most %d allowed", n, sizes.ExtraMax)})
+	}
+	if n := len(d.Side); n > sizes.SideMax {
+		v.add(Violation{Rule: RuleSideSize, Section: SectionSide,
+			Message: fmt.Sprintf("side deck has %d cards, at most %d allowed", n, sizes.SideMax)})
+	}
+
+	type group struct {
+		count int
+		codes []uint32
+	}
+	groups := make(map[uint32]*group)
+	var order []uint32
+	reported := make(map[uint32]bool)
+
+	for _, section := range []string{SectionMain, SectionExtra, SectionSide} {
+		for _, code := range d.Section(section) {
+			card, err := db.GetCard(code)
+			if err != nil {
+				return nil, err
+			}
+			if card == nil {
+				v.add(Violation{Rule: RuleUnknownCard, Section: section, Code: code,
+					Message: fmt.Sprintf("%s: card %d is not in the card database", section, code)})
+				continue
+			}
+
+			name, err := v.name(code)
+			if err != nil {
+				return nil, err
+			}
+
+			if card.IsToken() {
+				v.add(Violation{Rule: RuleWrongSection, Section: section, Code: code, Name: name,
+					Message: fmt.Sprintf("%s: %s is a token and cannot be in a deck", section, name)})
+			} else if section == SectionMain && card.IsExtraDeck() {
+				v.add(Violation{Rule: RuleWrongSection, Section: section, Code: code, Name: name,
+					Message: fmt.Sprintf("main: %s is an Extra Deck monster", name)})
+			} else if section == SectionExtra && !card.IsExtraDeck() {
+				v.add(Violation{Rule: RuleWrongSection, Section: section, Code: code, Name: name,
+					Message: fmt.Sprintf("extra: %s is not an Extra Deck monster", name)})
+			}
+
+			if !format.allows(card) && !reported[code] {
+				reported[code] = true
+				v.add(Violation{Rule: RuleFormat, Section: section, Code: code, Name: name,
+					Message: fmt.Sprintf("%s: %s is not legal in %s (released: %s)", section, name, format, card.OT)})
+			}
+
+			root, err := db.Canonical(code)
+			if err != nil {
+				return nil, err
+			}
+			g, ok := groups[root]
+			if !ok {
+				g = &group{}
+				groups[root] = g
+				order = append(order, root)
+			}
+			g.count++
+			g.codes = append(g.codes, code)
+		}
+	}
+
+	for _, root := range order {
+		g := groups[root]
+		name, err := v.name(root)
+		if err != nil {
+			return nil, err
+		}
+
+		if g.count > sizes.Copies {
+			v.add(Violation{Rule: RuleCopies, Code: root, Name: name,
+				Message: fmt.Sprintf("%s of %s, at most %d allowed", copiesText(g.count), name, sizes.Copies)})
+		}
+
+		if list == nil {
+			continue
+		}
+		limit := list.Limit(root)
+		for _, code := range g.codes {
+			if l := list.Limit(code); l < limit {
+				limit = l
+			}
+		}
+		if g.count > limit {
+			v.add(Violation{Rule: RuleBanlist, Code: root, Name: name,
+				Message: fmt.Sprintf("%s of %s, %s allows %s", copiesText(g.count), name, list.Name, limitText(limit))})
+		}
+	}
+
+	return v.violations, nil
+}
+
+func copiesText(n int) string {
+	if n == 1 {
+		return "1 copy"
+	}
+	return fmt.Sprintf("%d copies", n)
+}
+
+func limitText(limit int) string {
+	switch limit {
+	case banlist.Forbidden:
+		return "none (Forbidden)"
+	case banlist.Limited:
+		return "1 (Limited)"
+	case banlist.SemiLimited:
+		return "2 (Semi-Limited)"
+	default:
+		return fmt.Sprint(limit)
+	}
+}
+
+type validator struct {
+	db         carddb.Source
+	names      map[uint32]string
+	violations []Violation
+}
+
+func (v *validator) add(violation Violation) {
+	v.violations = append(v.violations, violation)
+}
+
+// name returns the card name, falling back to the code when the texts
+// table has no entry.
+func (v *validator) name(code uint32) (string, error) {
+	if n, ok := v.names[code]; ok {
+		return n, nil
+	}
+	text, err := v.db.GetText(code)
+	if err != nil {
+		return "", err
+	}
+	n := fmt.Sprint(code)
+	if text != nil && text.Name != "" {
+		n = text.Name
+	}
+	v.names[code] = n
+	return n, nil
+}
@@ -0,0 +1,114 @@
+package deck
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ParseYDK reads a .ydk deck file:
+//
+//	#created by Player
+//	#main
+//	69140098
+//	#extra
+//	44508094
+//	!side
+//	55144522
+//
+// Card order is preserved. Blank lines and unrecognised "#" lines are
+// skipped; "#" lines before #main are kept in Deck.Comments.
+func ParseYDK(r io.Reader) (*Deck, error) {
+	d := &Deck{}
+	var section *[]uint32
+	seenMain := false
+
+	sc := bufio.NewScanner(r)
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		line := strings.TrimSpace(sc.Text())
+		if lineNo == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		if line == "" {
+			continue
+		}
+
+		switch {
+		case line == "#main":
+			section, seenMain = &d.Main, true
+		case line == "#extra":
+			section = &d.Extra
+		case line == "!side":
+			section = &d.Side
+		case strings.HasPrefix(line, "#created by"):
+			d.CreatedBy = strings.TrimSpace(strings.TrimPrefix(line, "#created by"))
+		case strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!"):
+			if !seenMain {
+				d.Comments = append(d.Comments, strings.TrimSpace(line[1:]))
+			}
+		default:
+			if section == nil {
+				return nil, fmt.Errorf("ydk line %d: card code before #main", lineNo)
+			}
+			code, err := strconv.ParseUint(line, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("ydk line %d: invalid card code %q", lineNo, line)
+			}
+			*section = append(*section, uint32(code))
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ydk: %w", err)
+	}
+	return d, nil
+}
+
+func LoadYDK(path string) (*Deck, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open deck: %w", err)
+	}
+	defer f.Close()
+	return ParseYDK(f)
+}
+
+// WriteYDK writes d in .ydk format, keeping card order.
+func (d *Deck) WriteYDK(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if d.CreatedBy != "" {
+		fmt.Fprintf(bw, "#created by %s\n", d.CreatedBy)
+	}
+	for _, c := range d.Comments {
+		fmt.Fprintf(bw, "#%s\n", c)
+	}
+	bw.WriteString("#main\n")
+	for _, code := range d.Main {
+		fmt.Fprintf(bw, "%d\n", code)
+	}
+	bw.WriteString("#extra\n")
+	for _, code := range d.Extra {
+		fmt.Fprintf(bw, "%d\n", code)
+	}
+	bw.WriteString("!side\n")
+	for _, code := range d.Side {
+		fmt.Fprintf(bw, "%d\n", code)
+	}
+	return bw.Flush()
+}
+
+func (d *Deck) SaveYDK(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create deck file: %w", err)
+	}
+	if err := d.WriteYDK(f); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write deck file: %w", err)
+	}
+	return f.Close()
+}
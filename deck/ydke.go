@@ -0,0 +1,93 @@
+package deck
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+const ydkePrefix = "ydke://"
+
+// ydkeEncoding is standard padded base64 with strict decoding, so stray
+// bits or characters in a pasted URL are rejected rather than ignored.
+var ydkeEncoding = base64.StdEncoding.Strict()
+
+// IsYDKE reports whether s looks like a ydke:// deck URL.
+func IsYDKE(s string) bool {
+	return strings.HasPrefix(strings.TrimSpace(s), ydkePrefix)
+}
+
+// ParseYDKE decodes a "ydke://main!extra!side!" URL. Each component is
+// base64 of a little-endian uint32 list; the trailing "!" is optional.
+func ParseYDKE(url string) (*Deck, error) {
+	url = strings.TrimSpace(url)
+	if !strings.HasPrefix(url, ydkePrefix) {
+		return nil, fmt.Errorf("ydke: missing %q prefix", ydkePrefix)
+	}
+	parts := strings.Split(strings.TrimPrefix(url, ydkePrefix), "!")
+	if len(parts) == 4 && parts[3] == "" {
+		parts = parts[:3]
+	}
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("ydke: expected 3 sections separated by '!', got %d", len(parts))
+	}
+
+	d := &Deck{}
+	sections := []*[]uint32{&d.Main, &d.Extra, &d.Side}
+	names := []string{SectionMain, SectionExtra, SectionSide}
+	for i, part := range parts {
+		codes, err := decodeYDKESection(part)
+		if err != nil {
+			return nil, fmt.Errorf("ydke: %s section: %w", names[i], err)
+		}
+		*sections[i] = codes
+	}
+	return d, nil
+}
+
+func decodeYDKESection(s string) ([]uint32, error) {
+	if s == "" {
+		return nil, nil
+	}
+	raw, err := ydkeEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64: %w", err)
+	}
+	if len(raw)%4 != 0 {
+		return nil, fmt.Errorf("decoded length %d is not a multiple of 4", len(raw))
+	}
+	codes := make([]uint32, 0, len(raw)/4)
+	for i := 0; i < len(raw); i += 4 {
+		code := binary.LittleEndian.Uint32(raw[i:])
+		if code == 0 {
+			return nil, fmt.Errorf("card #%d has code 0", i/4+1)
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// FormatYDKE encodes d as a ydke:// URL. CreatedBy and comments are not
+// representable and are dropped.
+func FormatYDKE(d *Deck) string {
+	var b strings.Builder
+	b.WriteString(ydkePrefix)
+	for _, codes := range [][]uint32{d.Main, d.Extra, d.Side} {
+		raw := make([]byte, 4*len(codes))
+		for i, code := range codes {
+			binary.LittleEndian.PutUint32(raw[4*i:], code)
+		}
+		b.WriteString(ydkeEncoding.EncodeToString(raw))
+		b.WriteByte('!')
+	}
+	return b.String()
+}
+
+// Load reads a deck from a ydke:// URL or, otherwise, a .ydk file path.
+func Load(pathOrURL string) (*Deck, error) {
+	if IsYDKE(pathOrURL) {
+		return ParseYDKE(pathOrURL)
+	}
+	return LoadYDK(pathOrURL)
+}
@@ -0,0 +1,65 @@
+package duelInterface
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/spb8026/ygo-visualizer/carddb"
+)
+
+// RunDiffCLI compares two cdb files and prints added, removed and changed
+// cards. Usage: diff [-json] old.cdb new.cdb
+func RunDiffCLI(args []string) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: diff [-json] old.cdb new.cdb\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	os.Exit(runDiff(fs.Arg(0), fs.Arg(1), *asJSON))
+}
+
+// runDiff does the work of RunDiffCLI and returns the exit code, so that its
+// deferred Closes run before the process exits.
+func runDiff(oldPath, newPath string, asJSON bool) int {
+	oldDB, err := carddb.Open(oldPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open old db: %v\n", err)
+		return 1
+	}
+	defer oldDB.Close()
+
+	newDB, err := carddb.Open(newPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open new db: %v\n", err)
+		return 1
+	}
+	defer newDB.Close()
+
+	report, err := carddb.Diff(oldDB, newDB)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to diff: %v\n", err)
+		return 1
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(report)
+	} else {
+		err = report.WriteText(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write report: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -1,50 +1,227 @@
 package duelInterface
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"image"
+	"image/png"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spb8026/ygo-visualizer/bridge"
+	"github.com/spb8026/ygo-visualizer/carddb"
+	"github.com/spb8026/ygo-visualizer/deck"
+	"github.com/spb8026/ygo-visualizer/narrate"
+	"github.com/spb8026/ygo-visualizer/render"
+	"github.com/spb8026/ygo-visualizer/replay"
+	"github.com/spb8026/ygo-visualizer/state"
 	duelpb "github.com/spb8026/ygo-visualizer/ygopenpb"
 	"google.golang.org/protobuf/proto"
 )
 
-func RunCLI() {
+// RunCLI plays a duel in the terminal. Usage: [-cdb cards.cdb] [-deck0 a.ydk] [-deck1 b.ydk]
+// [-narrate quiet|normal|verbose|raw] [-lang en] [-cdb-lang cards-ja.cdb] [-board] [-ascii]
+// [-svg dir] [-png dir] [-gif out.gif] [-gif-turns 2-3] [-gif-events 100-200]
+// [-gif-delay turn=2s,card=500ms] [-pics dir] [-record out.json] [-crosscheck] [-preload]
+// Decks may also be given as ydke:// URLs. Messages are narrated in -lang,
+// with card text from -cdb-lang where it has it, unless -narrate=raw prints
+// the proto text. -board redraws the field after every step at the
+// terminal's width; -svg and -png also write it to dir/step-NNNN.svg or
+// .png. -gif records an animation of the turns or events in range, one
+// frame per event, written when the duel is left. -pics draws face-up
+// cards with their artwork in PNG and GIF frames. -record saves every
+// message for the replay command, when the duel is left. -crosscheck reports
+// where the narrator's board diverges from the core after every step.
+// -preload reads the whole card database into memory before the duel.
+// Each step waits for Enter; the duel is left on q, when it ends, or when
+// it asks for more than an idle or chain answer.
+func RunCLI(args []string) {
+	os.Exit(runCLI(args))
+}
+
+// runCLI is RunCLI returning its exit code, so that the duel and the
+// databases are closed before the process exits.
+func runCLI(args []string) int {
+	fs := flag.NewFlagSet("duel", flag.ExitOnError)
+	cdbPath := fs.String("cdb", "", "card database (.cdb) used by the core")
+	deckPaths := [2]*string{
+		fs.String("deck0", "", "deck (.ydk path or ydke:// URL) for player 0"),
+		fs.String("deck1", "", "deck (.ydk path or ydke:// URL) for player 1"),
+	}
+	narration := fs.String("narrate", "normal", "narration detail: quiet, normal, verbose, or raw for proto text")
+	lang := fs.String("lang", narrate.DefaultLanguage, "narration language: "+strings.Join(narrate.Languages(), ", "))
+	textPath := fs.String("cdb-lang", "", "translated card database whose names and texts override -cdb")
+	showBoard := fs.Bool("board", false, "draw the field after each step")
+	ascii := fs.Bool("ascii", false, "draw the field with ASCII characters only")
+	svgDir := fs.String("svg", "", "directory to write an SVG snapshot of the field to after each step")
+	pngDir := fs.String("png", "", "directory to write a PNG frame of the field to after each step")
+	gifPath := fs.String("gif", "", "animated GIF to record the duel to")
+	gifTurns := fs.String("gif-turns", "", "turns to record, as from-to; either end may be left open")
+	gifEvents := fs.String("gif-events", "", "events to record, numbered from 1, as from-to")
+	gifDelays := fs.String("gif-delay", "", "frame time by event kind, as kind=duration,...")
+	picsDir := fs.String("pics", "", "directory of card pictures named <code>.jpg or <code>.png")
+	recordPath := fs.String("record", "", "file to save the duel's messages to, for the replay command")
+	crossCheck := fs.Bool("crosscheck", false, "compare the Go-side board with core queries after each step")
+	preload := fs.Bool("preload", false, "load every card of -cdb into memory before the duel")
+	fs.Parse(args)
+	if *textPath != "" && *cdbPath == "" {
+		fmt.Fprintln(os.Stderr, "-cdb-lang needs -cdb")
+		fs.Usage()
+		return 2
+	}
+
+	var db *carddb.DB
+	var cache *carddb.Cache
+	if *cdbPath != "" {
+		var err error
+		db, err = carddb.Open(*cdbPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open card db: %v\n", err)
+			return 1
+		}
+		defer db.Close()
+		cache = carddb.NewCache(db)
+		if *preload {
+			n, err := cache.Preload()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+			fmt.Printf("Preloaded %d cards\n", n)
+		}
+		bridge.SetCardCache(cache)
+	}
+
+	decks, err := loadDecks([2]string{*deckPaths[0], *deckPaths[1]}, db)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	seed := [4]uint64{12345, 0, 0, 0}
 	duel, err := bridge.NewDuel(bridge.DuelOptions{
-		Seed:              [4]uint64{12345, 0, 0, 0},
+		Seed:              seed,
 		StartingLP:        8000,
 		StartingDrawCount: 5,
 		DrawCountPerTurn:  1,
 	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to create duel: %v\n", err)
-		os.Exit(1)
+		return 1
 	}
 	defer duel.Close()
 
-	const GeminiElf = uint32(69140098)
-	for i := 0; i < 40; i++ {
-		duel.AddCard(0, 0, GeminiElf, 0, bridge.LOC_DECK, uint32(i), bridge.POS_FACEDOWN)
-		duel.AddCard(1, 0, GeminiElf, 1, bridge.LOC_DECK, uint32(i), bridge.POS_FACEDOWN)
+	decks[0].AddTo(duel, 0)
+	decks[1].AddTo(duel, 1)
+
+	var src carddb.Source
+	if cache != nil {
+		src = cache
+		if *textPath != "" {
+			text, err := carddb.Open(*textPath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to open translated card db: %v\n", err)
+				return 1
+			}
+			defer text.Close()
+			src = carddb.NewLayered(carddb.NewCache(text), src)
+		}
+	}
+	raw := *narration == "raw"
+	verbosity := narrate.Normal
+	if !raw {
+		verbosity, err = narrate.ParseVerbosity(*narration)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+	narrator, err := narrate.NewLocalized(src, *lang, verbosity)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	for _, dir := range []string{*svgDir, *pngDir} {
+		if dir == "" {
+			continue
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create snapshot directory: %v\n", err)
+			return 1
+		}
+	}
+	var art *render.Artwork
+	if *picsDir != "" {
+		art = render.NewArtwork(*picsDir)
+	}
+	var anim *render.Animation
+	if *gifPath != "" {
+		opts := render.AnimationOptions{Frame: render.FrameOptions{DB: src, Art: art}}
+		if opts.FromTurn, opts.ToTurn, err = parseRange(*gifTurns); err != nil {
+			fmt.Fprintf(os.Stderr, "Bad -gif-turns: %v\n", err)
+			return 1
+		}
+		if opts.FromEvent, opts.ToEvent, err = parseRange(*gifEvents); err != nil {
+			fmt.Fprintf(os.Stderr, "Bad -gif-events: %v\n", err)
+			return 1
+		}
+		if opts.Delays, err = parseDelays(*gifDelays); err != nil {
+			fmt.Fprintf(os.Stderr, "Bad -gif-delay: %v\n", err)
+			return 1
+		}
+		anim = render.NewAnimation(opts)
+	}
+	var rec *replay.Recording
+	if *recordPath != "" {
+		rec = &replay.Recording{Seed: seed, Decks: decks}
 	}
 
 	duel.Start()
-	for {
+	for step := 0; ; step++ {
 		status, msgs, err := duel.Step()
 		fmt.Printf("Status: %d, Messages: %d, Error: %v\n", status, len(msgs), err)
+		if err != nil {
+			break
+		}
 
+		finished, answered := false, false
 		for _, b := range msgs {
 			var m duelpb.Msg
 			if err := proto.Unmarshal(b, &m); err != nil {
 				fmt.Printf("  decode error: %v\n", err)
 				continue
 			}
-			fmt.Printf("  Msg: %s\n", m.String())
+			lines, err := narrator.Narrate(&m)
+			if err != nil {
+				fmt.Printf("  board error: %v\n", err)
+			}
+			if anim != nil {
+				anim.Add(narrator.Board(), &m)
+			}
+			if rec != nil {
+				rec.Add(&m)
+			}
+			if m.GetEvent().GetFinish() != nil {
+				finished = true
+			}
+			if raw {
+				fmt.Printf("  Msg: %s\n", m.String())
+			} else {
+				for _, line := range lines {
+					fmt.Printf("  %s\n", line)
+				}
+			}
 			if sel := m.GetRequest().GetSelectIdle(); sel != nil {
 				err := duel.SendIdlePhase(sel.GetAvailablePhase())
 				if err != nil {
 					fmt.Printf("  answer error: %v\n", err)
 				} else {
+					answered = true
 					fmt.Printf("  sent phase answer: %d\n", sel.GetAvailablePhase())
 				}
 			}
@@ -53,15 +230,233 @@ func RunCLI() {
 				if err != nil {
 					fmt.Printf("  answer error: %v\n", err)
 				} else {
+					answered = true
 					fmt.Printf("  sent no-op chain answer\n")
 				}
 			}
 		}
 
+		if *showBoard {
+			fmt.Print(render.Text(narrator.Board(), render.TextOptions{
+				Width: terminalWidth(),
+				DB:    src,
+				ASCII: *ascii,
+			}))
+		}
+
+		if *svgDir != "" {
+			path := filepath.Join(*svgDir, fmt.Sprintf("step-%04d.svg", step))
+			svg := render.SVG(narrator.Board(), render.SVGOptions{DB: src})
+			if err := os.WriteFile(path, []byte(svg), 0o644); err != nil {
+				fmt.Printf("  snapshot error: %v\n", err)
+			}
+		}
+		if *pngDir != "" {
+			path := filepath.Join(*pngDir, fmt.Sprintf("step-%04d.png", step))
+			img := render.Frame(narrator.Board(), render.FrameOptions{DB: src, Art: art})
+			if err := writePNG(path, img); err != nil {
+				fmt.Printf("  snapshot error: %v\n", err)
+			}
+		}
+
+		if *crossCheck {
+			divs, err := state.CrossCheck(narrator.Board(), duel)
+			if err != nil {
+				fmt.Printf("  crosscheck error: %v\n", err)
+			}
+			for _, d := range divs {
+				fmt.Printf("  DIVERGENCE %s\n", d)
+			}
+		}
+
+		if finished || status == bridge.DuelStatusEnd {
+			fmt.Println("The duel is over.")
+			break
+		}
+		if status == bridge.DuelStatusAwaiting && !answered {
+			// Only idle and chain requests are answered here; any other
+			// request would stall the duel.
+			fmt.Println("The duel is waiting for an answer this command cannot give.")
+			break
+		}
+
 		var input string
 		fmt.Scanln(&input)
 		if input == "q" {
 			break
 		}
 	}
+
+	if anim != nil {
+		if err := writeGIF(*gifPath, anim); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write GIF: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Wrote %d frames to %s\n", anim.Frames(), *gifPath)
+	}
+	if rec != nil {
+		if err := rec.Save(*recordPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to save recording: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Recorded %d messages to %s\n", len(rec.Messages), *recordPath)
+	}
+	return 0
+}
+
+func writePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+func writeGIF(path string, anim *render.Animation) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := anim.WriteGIF(f); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// parseRange reads "from-to", "from-", "-to" or a single number. Open ends
+// are 0; an empty string is fully open.
+func parseRange(s string) (from, to int, err error) {
+	if s == "" {
+		return 0, 0, nil
+	}
+	lo, hi, ok := strings.Cut(s, "-")
+	if !ok {
+		hi = lo
+	}
+	if lo != "" {
+		if from, err = strconv.Atoi(lo); err != nil {
+			return 0, 0, fmt.Errorf("bad range start %q: %w", lo, err)
+		}
+	}
+	if hi != "" {
+		if to, err = strconv.Atoi(hi); err != nil {
+			return 0, 0, fmt.Errorf("bad range end %q: %w", hi, err)
+		}
+	}
+	if from < 0 || to < 0 || (to > 0 && from > to) {
+		return 0, 0, fmt.Errorf("empty range %q", s)
+	}
+	return from, to, nil
+}
+
+// parseDelays reads "kind=duration,..." with kinds as in render.EventKind.
+func parseDelays(s string) (map[string]time.Duration, error) {
+	delays := make(map[string]time.Duration)
+	if s == "" {
+		return delays, nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		kind, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("%q is not kind=duration", part)
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("bad duration for %s: %w", kind, err)
+		}
+		delays[kind] = d
+	}
+	return delays, nil
+}
+
+// loadDecks loads both players' decks, warning about cards db doesn't
+// know. An empty path gets defaultDeck.
+func loadDecks(paths [2]string, db *carddb.DB) ([2]*deck.Deck, error) {
+	var decks [2]*deck.Deck
+	for p, path := range paths {
+		decks[p] = defaultDeck()
+		if path == "" {
+			continue
+		}
+		d, err := deck.Load(path)
+		if err != nil {
+			return decks, fmt.Errorf("failed to load deck for player %d: %w", p, err)
+		}
+		if db != nil {
+			unknown, err := d.Unknown(db)
+			if err != nil {
+				return decks, fmt.Errorf("failed to check deck for player %d: %w", p, err)
+			}
+			for _, u := range unknown {
+				fmt.Fprintf(os.Stderr, "Warning: player %d deck %s\n", p, u)
+			}
+		}
+		decks[p] = d
+	}
+	return decks, nil
+}
+
+// frontEnd shows a duel and answers its requests: the TUI or the server.
+type frontEnd interface {
+	Feed(m *duelpb.Msg) error
+	Respond(req *duelpb.Msg_Request) (*duelpb.Answer, error)
+}
+
+// play runs the duel through fe until it ends, returning how it ended.
+func play(fe frontEnd, duel *bridge.Duel) (string, error) {
+	duel.Start()
+	for {
+		status, msgs, err := duel.Step()
+		if err != nil {
+			return "", fmt.Errorf("failed to step the duel: %w", err)
+		}
+
+		var req *duelpb.Msg_Request
+		for _, b := range msgs {
+			var m duelpb.Msg
+			if err := proto.Unmarshal(b, &m); err != nil {
+				return "", fmt.Errorf("failed to decode message: %w", err)
+			}
+			if err := fe.Feed(&m); err != nil {
+				return "", err
+			}
+			if r := m.GetRequest(); r != nil {
+				req = r
+			}
+			if m.GetEvent().GetFinish() != nil {
+				return "The duel is over.", nil
+			}
+		}
+
+		switch status {
+		case bridge.DuelStatusEnd:
+			return "The duel has ended.", nil
+		case bridge.DuelStatusAwaiting:
+			if req == nil {
+				return "", errors.New("core is waiting without a request")
+			}
+			ans, err := fe.Respond(req)
+			if err != nil {
+				return "", err
+			}
+			if err := duel.SendAnswer(ans); err != nil {
+				return "", fmt.Errorf("failed to send answer: %w", err)
+			}
+		}
+	}
+}
+
+// defaultDeck is used when no .ydk is given: 40 copies of Gemini Elf.
+func defaultDeck() *deck.Deck {
+	const GeminiElf = uint32(69140098)
+	d := &deck.Deck{}
+	for i := 0; i < 40; i++ {
+		d.Main = append(d.Main, GeminiElf)
+	}
+	return d
 }
@@ -0,0 +1,90 @@
+package duelInterface
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spb8026/ygo-visualizer/carddb"
+	"github.com/spb8026/ygo-visualizer/narrate"
+	"github.com/spb8026/ygo-visualizer/replay"
+)
+
+// RunReplayCLI exports a duel saved with -record as a single HTML page
+// that replays it in a browser, offline. Usage: replay [-cdb cards.cdb]
+// [-cdb-lang cards-ja.cdb] [-lang en] [-title name] [-o out.html] duel.json
+// The page is written next to the recording unless -o says otherwise.
+func RunReplayCLI(args []string) {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	cdbPath := fs.String("cdb", "", "card database (.cdb) for card names and text")
+	textPath := fs.String("cdb-lang", "", "translated card database whose names and texts override -cdb")
+	lang := fs.String("lang", narrate.DefaultLanguage, "narration language: "+strings.Join(narrate.Languages(), ", "))
+	title := fs.String("title", "", "page title (default: the recording's file name)")
+	outPath := fs.String("o", "", "HTML file to write (default: the recording with .html)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: replay [flags] duel.json\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *textPath != "" && *cdbPath == "" {
+		fmt.Fprintln(os.Stderr, "-cdb-lang needs -cdb")
+		fs.Usage()
+		os.Exit(2)
+	}
+	in := fs.Arg(0)
+
+	rec, err := replay.Load(in)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load recording: %v\n", err)
+		os.Exit(1)
+	}
+
+	var src carddb.Source
+	if *cdbPath != "" {
+		db, err := carddb.Open(*cdbPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open card db: %v\n", err)
+			os.Exit(1)
+		}
+		defer db.Close()
+		src = carddb.NewCache(db)
+		if *textPath != "" {
+			text, err := carddb.Open(*textPath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to open translated card db: %v\n", err)
+				os.Exit(1)
+			}
+			defer text.Close()
+			src = carddb.NewLayered(carddb.NewCache(text), src)
+		}
+	}
+
+	name := strings.TrimSuffix(filepath.Base(in), filepath.Ext(in))
+	if *title == "" {
+		*title = name
+	}
+	if *outPath == "" {
+		*outPath = filepath.Join(filepath.Dir(in), name+".html")
+	}
+
+	f, err := os.Create(*outPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create page: %v\n", err)
+		os.Exit(1)
+	}
+	err = replay.WriteHTML(f, rec, replay.HTMLOptions{DB: src, Lang: *lang, Title: *title})
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write page: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Wrote %s\n", *outPath)
+}
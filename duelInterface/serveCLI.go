@@ -0,0 +1,211 @@
+package duelInterface
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/spb8026/ygo-visualizer/bridge"
+	"github.com/spb8026/ygo-visualizer/carddb"
+	"github.com/spb8026/ygo-visualizer/narrate"
+	"github.com/spb8026/ygo-visualizer/server"
+)
+
+// RunServe plays a duel in the browser: it serves the board at -addr and
+// streams the duel to everyone watching. Usage: [-addr localhost:8080]
+// [-cdb cards.cdb] [-deck0 a.ydk] [-deck1 b.ydk] [-auto 1]
+// [-narrate quiet|normal|verbose] [-lang en] [-cdb-lang cards-ja.cdb]
+// Players listed in -auto pass whenever they can; the others are answered
+// from the browser through the per-seat links printed at startup.
+func RunServe(args []string) {
+	os.Exit(runServe(args))
+}
+
+// runServe is RunServe returning its exit code, so that the duel and the
+// databases are closed before the process exits.
+func runServe(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:8080", "address to listen on")
+	cdbPath := fs.String("cdb", "", "card database (.cdb) used by the core")
+	deckPaths := [2]*string{
+		fs.String("deck0", "", "deck (.ydk path or ydke:// URL) for player 0"),
+		fs.String("deck1", "", "deck (.ydk path or ydke:// URL) for player 1"),
+	}
+	autoList := fs.String("auto", "", "comma-separated players answered automatically: 0, 1 or 0,1")
+	narration := fs.String("narrate", "normal", "log detail: quiet, normal or verbose")
+	lang := fs.String("lang", narrate.DefaultLanguage, "log language: "+strings.Join(narrate.Languages(), ", "))
+	textPath := fs.String("cdb-lang", "", "translated card database whose names and texts override -cdb")
+	fs.Parse(args)
+	if *textPath != "" && *cdbPath == "" {
+		fmt.Fprintln(os.Stderr, "-cdb-lang needs -cdb")
+		fs.Usage()
+		return 2
+	}
+
+	var auto [2]bool
+	if *autoList != "" {
+		for _, p := range strings.Split(*autoList, ",") {
+			switch strings.TrimSpace(p) {
+			case "0":
+				auto[0] = true
+			case "1":
+				auto[1] = true
+			default:
+				fmt.Fprintf(os.Stderr, "Unknown player %q in -auto: want 0 or 1\n", p)
+				return 1
+			}
+		}
+	}
+	verbosity, err := narrate.ParseVerbosity(*narration)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	var db *carddb.DB
+	var src carddb.Source
+	if *cdbPath != "" {
+		db, err = carddb.Open(*cdbPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open card db: %v\n", err)
+			return 1
+		}
+		defer db.Close()
+		bridge.SetCardDB(db)
+		src = carddb.NewCache(db)
+		if *textPath != "" {
+			text, err := carddb.Open(*textPath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to open translated card db: %v\n", err)
+				return 1
+			}
+			defer text.Close()
+			src = carddb.NewLayered(carddb.NewCache(text), src)
+		}
+	}
+	decks, err := loadDecks([2]string{*deckPaths[0], *deckPaths[1]}, db)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	duel, err := bridge.NewDuel(bridge.DuelOptions{
+		Seed:              [4]uint64{12345, 0, 0, 0},
+		StartingLP:        8000,
+		StartingDrawCount: 5,
+		DrawCountPerTurn:  1,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create duel: %v\n", err)
+		return 1
+	}
+	defer duel.Close()
+	decks[0].AddTo(duel, 0)
+	decks[1].AddTo(duel, 1)
+
+	var tokens [2]string
+	for p := range tokens {
+		if tokens[p], err = seatToken(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to make a seat token: %v\n", err)
+			return 1
+		}
+	}
+	srv, err := server.New(server.Options{DB: src, Lang: *lang, Verbosity: verbosity, Auto: auto, Tokens: tokens})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer srv.Close()
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to listen: %v\n", err)
+		return 1
+	}
+	hs := &http.Server{Handler: srv.Handler()}
+	defer hs.Close()
+	served := make(chan error, 1)
+	go func() { served <- hs.Serve(ln) }()
+
+	base := "http://" + displayAddr(ln.Addr()) + "/"
+	fmt.Printf("Serving the duel at %s\n", base)
+	for p, tok := range tokens {
+		fmt.Printf("  Player %d: %s?seat=%d&token=%s\n", p+1, base, p, tok)
+	}
+	fmt.Printf("  Spectators: %s?seat=spectator\n", base)
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	type outcome struct {
+		msg string
+		err error
+	}
+	played := make(chan outcome, 1)
+	go func() {
+		msg, err := play(srv, duel)
+		played <- outcome{msg, err}
+	}()
+
+	// stop closes the server and waits for the duel to leave the core, which
+	// it does at its next prompt, before the deferred Closes run.
+	stop := func() {
+		srv.Close()
+		<-played
+	}
+	select {
+	case <-interrupt:
+		stop()
+		return 0
+	case err := <-served:
+		fmt.Fprintf(os.Stderr, "Server stopped: %v\n", err)
+		stop()
+		return 1
+	case o := <-played:
+		if o.err != nil && !errors.Is(o.err, server.ErrClosed) {
+			srv.End("The duel stopped: " + o.err.Error())
+			fmt.Fprintln(os.Stderr, o.err)
+		} else {
+			srv.End(o.msg)
+			fmt.Println(o.msg)
+		}
+	}
+
+	fmt.Println("Still serving; press Ctrl+C to stop.")
+	select {
+	case <-interrupt:
+		return 0
+	case err := <-served:
+		fmt.Fprintf(os.Stderr, "Server stopped: %v\n", err)
+		return 1
+	}
+}
+
+// seatToken returns a random secret for a player's seat link.
+func seatToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// displayAddr turns a listening address into one a browser can open,
+// replacing an unspecified host with localhost.
+func displayAddr(a net.Addr) string {
+	host, port, err := net.SplitHostPort(a.String())
+	if err != nil {
+		return a.String()
+	}
+	if ip := net.ParseIP(host); ip == nil || ip.IsUnspecified() {
+		host = "localhost"
+	}
+	return net.JoinHostPort(host, port)
+}
@@ -0,0 +1,13 @@
+package duelInterface
+
+import (
+	"os"
+	"strconv"
+)
+
+func columnsEnv() int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	return 80
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package duelInterface
+
+// terminalWidth returns $COLUMNS, or 80.
+func terminalWidth() int {
+	return columnsEnv()
+}
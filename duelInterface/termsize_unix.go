@@ -0,0 +1,18 @@
+//go:build unix
+
+package duelInterface
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// terminalWidth returns the width of the terminal on stdout, falling back
+// to $COLUMNS and then 80.
+func terminalWidth() int {
+	if ws, err := unix.IoctlGetWinsize(int(os.Stdout.Fd()), unix.TIOCGWINSZ); err == nil && ws.Col > 0 {
+		return int(ws.Col)
+	}
+	return columnsEnv()
+}
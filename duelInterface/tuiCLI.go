@@ -0,0 +1,127 @@
+package duelInterface
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spb8026/ygo-visualizer/bridge"
+	"github.com/spb8026/ygo-visualizer/carddb"
+	"github.com/spb8026/ygo-visualizer/narrate"
+	"github.com/spb8026/ygo-visualizer/tui"
+)
+
+// RunTUI plays a duel full-screen, answering every request from the
+// keyboard. Usage: [-cdb cards.cdb] [-deck0 a.ydk] [-deck1 b.ydk]
+// [-seat auto|0|1] [-narrate quiet|normal|verbose] [-lang en] [-cdb-lang cards-ja.cdb]
+// With -seat auto both players share the terminal and the board turns to
+// whoever has to answer.
+func RunTUI(args []string) {
+	os.Exit(runTUI(args))
+}
+
+// runTUI is RunTUI returning its exit code, so that the duel and the
+// databases are closed before the process exits.
+func runTUI(args []string) int {
+	fs := flag.NewFlagSet("tui", flag.ExitOnError)
+	cdbPath := fs.String("cdb", "", "card database (.cdb) used by the core")
+	deckPaths := [2]*string{
+		fs.String("deck0", "", "deck (.ydk path or ydke:// URL) for player 0"),
+		fs.String("deck1", "", "deck (.ydk path or ydke:// URL) for player 1"),
+	}
+	seatName := fs.String("seat", "auto", "player at the bottom of the screen: auto, 0 or 1")
+	narration := fs.String("narrate", "normal", "log detail: quiet, normal or verbose")
+	lang := fs.String("lang", narrate.DefaultLanguage, "log language: "+strings.Join(narrate.Languages(), ", "))
+	textPath := fs.String("cdb-lang", "", "translated card database whose names and texts override -cdb")
+	fs.Parse(args)
+	if *textPath != "" && *cdbPath == "" {
+		fmt.Fprintln(os.Stderr, "-cdb-lang needs -cdb")
+		fs.Usage()
+		return 2
+	}
+
+	seat := tui.FollowReplier
+	switch *seatName {
+	case "auto":
+	case "0":
+		seat = 0
+	case "1":
+		seat = 1
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown seat %q: want auto, 0 or 1\n", *seatName)
+		return 1
+	}
+	verbosity, err := narrate.ParseVerbosity(*narration)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	var db *carddb.DB
+	var src carddb.Source
+	if *cdbPath != "" {
+		db, err = carddb.Open(*cdbPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open card db: %v\n", err)
+			return 1
+		}
+		defer db.Close()
+		bridge.SetCardDB(db)
+		src = carddb.NewCache(db)
+		if *textPath != "" {
+			text, err := carddb.Open(*textPath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to open translated card db: %v\n", err)
+				return 1
+			}
+			defer text.Close()
+			src = carddb.NewLayered(carddb.NewCache(text), src)
+		}
+	}
+	decks, err := loadDecks([2]string{*deckPaths[0], *deckPaths[1]}, db)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	duel, err := bridge.NewDuel(bridge.DuelOptions{
+		Seed:              [4]uint64{12345, 0, 0, 0},
+		StartingLP:        8000,
+		StartingDrawCount: 5,
+		DrawCountPerTurn:  1,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create duel: %v\n", err)
+		return 1
+	}
+	defer duel.Close()
+	decks[0].AddTo(duel, 0)
+	decks[1].AddTo(duel, 1)
+
+	// The terminal is restored before any error is printed, and also if
+	// the duel panics.
+	err = func() error {
+		ui, err := tui.New(tui.Options{DB: src, Lang: *lang, Verbosity: verbosity, Seat: seat})
+		if err != nil {
+			return err
+		}
+		defer ui.Close()
+		return playTUI(ui, duel)
+	}()
+	if err != nil && !errors.Is(err, tui.ErrQuit) {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// playTUI runs the duel until it ends or the user quits.
+func playTUI(ui *tui.UI, duel *bridge.Duel) error {
+	end, err := play(ui, duel)
+	if err != nil {
+		return err
+	}
+	return ui.Wait(end + " Press any key to leave.")
+}
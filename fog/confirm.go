@@ -0,0 +1,45 @@
+package fog
+
+import (
+	duelpb "github.com/spb8026/ygo-visualizer/ygopenpb"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// confirmPlayerField is the field of Meta.Confirm in which the bridge
+// passes the player the cards are confirmed to. YGOpen's message has no
+// such field, so it travels as an unknown field; see bridge.cpp.
+const confirmPlayerField = 1000
+
+// ConfirmedTo returns the player c confirms its cards to, and false when c
+// does not say.
+func ConfirmedTo(c *duelpb.Msg_Event_Meta_Confirm) (int, bool) {
+	b := c.ProtoReflect().GetUnknown()
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return 0, false
+		}
+		b = b[n:]
+		if num == confirmPlayerField && typ == protowire.VarintType {
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return 0, false
+			}
+			return int(v), true
+		}
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		if n < 0 {
+			return 0, false
+		}
+		b = b[n:]
+	}
+	return 0, false
+}
+
+// SetConfirmedTo records that c confirms its cards to player, as the
+// bridge does. Recordings use it to restore what their JSON drops.
+func SetConfirmedTo(c *duelpb.Msg_Event_Meta_Confirm, player int) {
+	r := c.ProtoReflect()
+	b := protowire.AppendTag(r.GetUnknown(), confirmPlayerField, protowire.VarintType)
+	r.SetUnknown(protowire.AppendVarint(b, uint64(player)))
+}
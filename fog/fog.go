@@ -0,0 +1,169 @@
+// Package fog filters the global duel message stream down to what one
+// viewer is allowed to know.
+package fog
+
+import (
+	"fmt"
+
+	"github.com/spb8026/ygo-visualizer/state"
+	duelpb "github.com/spb8026/ygo-visualizer/ygopenpb"
+	"google.golang.org/protobuf/proto"
+)
+
+// Viewer is a player index or Spectator.
+type Viewer int
+
+const (
+	Spectator Viewer = -1
+	Player0   Viewer = 0
+	Player1   Viewer = 1
+)
+
+func (v Viewer) String() string {
+	if v == Spectator {
+		return "spectator"
+	}
+	return fmt.Sprintf("player %d", int(v))
+}
+
+// Visible reports whether v may know the identity of a card with the given
+// position at con/loc. Revealed (public) cards and Xyz materials are
+// always visible; the deck never is.
+func Visible(v Viewer, con int, loc, position uint32, public bool) bool {
+	if public || loc&state.LOC_OVERLAY != 0 {
+		return true
+	}
+	own := v == Viewer(con)
+	faceUp := position&state.POS_FACEUP != 0
+	switch loc {
+	case state.LOC_DECK:
+		return false
+	case state.LOC_HAND:
+		return own
+	case state.LOC_GRAVE:
+		return true
+	case state.LOC_EXTRA, state.LOC_MZONE, state.LOC_SZONE, state.LOC_REMOVED:
+		return own || faceUp
+	}
+	return own
+}
+
+// Redact returns the copy of m that v may see, or nil when the whole
+// message is private to someone else (requests addressed to the other
+// player). global must already have m applied, so it reflects the board
+// right after the event.
+//
+// Hidden cards keep their places and counts; their queries are reduced to
+// position and ownership, with the code zeroed so a viewer board forgets a
+// card that went out of sight.
+//
+// Meta_Confirm reveals its cards in the same message, except cards
+// confirmed from a deck, which only the player they are confirmed to sees
+// (looking at a deck is private, revealing a hand card is not). A confirm
+// that does not name its player is taken to be addressed to the deck's
+// controller.
+func Redact(global *state.Board, m *duelpb.Msg, v Viewer) *duelpb.Msg {
+	if req := m.GetRequest(); req != nil {
+		if v == Spectator || Viewer(req.GetReplier()) != v {
+			return nil
+		}
+		return m
+	}
+
+	revealed := make(map[string]bool)
+	if confirm := m.GetEvent().GetMeta().GetConfirm(); confirm != nil {
+		to, named := ConfirmedTo(confirm)
+		for _, place := range confirm.GetPlaces() {
+			if !named {
+				to = int(place.GetCon())
+			}
+			if place.GetLoc() == state.LOC_DECK && Viewer(to) != v {
+				continue
+			}
+			revealed[state.PlaceString(place)] = true
+		}
+	}
+
+	out := proto.Clone(m).(*duelpb.Msg)
+	for _, q := range out.GetQueries() {
+		place := q.GetPlace()
+		if revealed[state.PlaceString(place)] {
+			continue
+		}
+
+		d := q.GetData()
+		position := d.GetPosition().GetValue()
+		public := d.GetIsPublic().GetValue()
+		if c := global.At(place); c != nil {
+			if d.GetPosition() == nil {
+				position = c.Position
+			}
+			if d.GetIsPublic() == nil {
+				public = c.Public
+			}
+		}
+		loc := place.GetLoc()
+		if place.GetOseq() >= 0 {
+			loc |= state.LOC_OVERLAY
+		}
+		if Visible(v, int(place.GetCon()), loc, position, public) {
+			continue
+		}
+		q.Data = hide(d)
+	}
+	return out
+}
+
+// hide keeps only the fields of a query that do not identify the card.
+func hide(d *duelpb.Msg_Query_Data) *duelpb.Msg_Query_Data {
+	h := &duelpb.Msg_Query_Data{
+		Position: d.GetPosition(),
+		Owner:    d.GetOwner(),
+		IsPublic: d.GetIsPublic(),
+		IsHidden: d.GetIsHidden(),
+		Cover:    d.GetCover(),
+		Counters: d.GetCounters(),
+	}
+	if d.GetCode() != nil {
+		h.Code = &duelpb.Msg_Query_Data_QCode{}
+	}
+	return h
+}
+
+// Filter keeps the global board needed to judge visibility and the board
+// as seen by one viewer.
+type Filter struct {
+	viewer Viewer
+	global *state.Board
+	view   *state.Board
+}
+
+func NewFilter(v Viewer) *Filter {
+	return &Filter{viewer: v, global: state.New(), view: state.New()}
+}
+
+func (f *Filter) Viewer() Viewer {
+	return f.viewer
+}
+
+// Apply feeds one global message through the filter and returns what the
+// viewer sees, or nil if the message is hidden from them entirely.
+func (f *Filter) Apply(m *duelpb.Msg) (*duelpb.Msg, error) {
+	if err := f.global.Apply(m); err != nil {
+		return nil, err
+	}
+	out := Redact(f.global, m, f.viewer)
+	if out == nil {
+		return nil, nil
+	}
+	if err := f.view.Apply(out); err != nil {
+		return nil, fmt.Errorf("%s view: %w", f.viewer, err)
+	}
+	return out, nil
+}
+
+// Board returns the viewer's board. Hidden cards are present with a zero
+// Code.
+func (f *Filter) Board() *state.Board {
+	return f.view
+}
@@ -1,9 +1,27 @@
 package main
 
 import (
+	"os"
+
 	duelInterface "github.com/spb8026/ygo-visualizer/duelInterface"
 )
 
 func main() {
-	duelInterface.RunCLI()
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "diff":
+			duelInterface.RunDiffCLI(os.Args[2:])
+			return
+		case "tui":
+			duelInterface.RunTUI(os.Args[2:])
+			return
+		case "replay":
+			duelInterface.RunReplayCLI(os.Args[2:])
+			return
+		case "serve":
+			duelInterface.RunServe(os.Args[2:])
+			return
+		}
+	}
+	duelInterface.RunCLI(os.Args[1:])
 }
@@ -0,0 +1,210 @@
+// Package match runs best-of-three matches on top of the bridge: side
+// decking between games, loser-chooses-first, and per-game seeds derived
+// from a single match seed so a whole match can be replayed.
+package match
+
+import (
+	"fmt"
+
+	"github.com/spb8026/ygo-visualizer/bridge"
+	"github.com/spb8026/ygo-visualizer/deck"
+	answerpb "github.com/spb8026/ygo-visualizer/ygopenpb"
+	duelpb "github.com/spb8026/ygo-visualizer/ygopenpb"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// MaxGames is the length of a match; the first player to WinsNeeded
+	// wins ends it early.
+	MaxGames   = 3
+	WinsNeeded = 2
+
+	// Draw is the Winner value of a drawn game or match.
+	Draw = -1
+)
+
+// Player is one side of a match. Methods are called from Run's goroutine.
+type Player interface {
+	// Respond answers a request the core addressed to this player.
+	Respond(req *duelpb.Msg_Request) (*answerpb.Answer, error)
+	// GoFirst is asked of the player choosing the turn order for game
+	// (1-based): the coin toss winner for game 1, the previous loser after.
+	GoFirst(game int) bool
+	// Side returns the deck for the next game, given the registered deck and
+	// the one just played. Returning current keeps it unchanged.
+	Side(game int, registered, current *deck.Deck) (*deck.Deck, error)
+}
+
+// Options configures a match. Duel.Seed is ignored: each game gets its own
+// seed derived from Seed.
+type Options struct {
+	Seed   [4]uint64
+	Decks  [2]*deck.Deck
+	Format deck.Format
+	Duel   bridge.DuelOptions
+
+	// OnMessage, if set, sees every decoded message of every game.
+	OnMessage func(game int, m *duelpb.Msg)
+}
+
+// GameResult records one game of a match. Players are always indexed as
+// in Options.Decks, whoever went first.
+type GameResult struct {
+	Game   int
+	Seed   [4]uint64
+	First  int
+	Winner int
+	Reason uint32
+	Turns  int
+	Decks  [2]*deck.Deck
+}
+
+// MatchResult is the outcome of Run.
+type MatchResult struct {
+	Seed   [4]uint64
+	Games  []GameResult
+	Wins   [2]int
+	Winner int
+}
+
+func (r *MatchResult) String() string {
+	winner := "draw"
+	if r.Winner != Draw {
+		winner = fmt.Sprintf("player %d", r.Winner)
+	}
+	return fmt.Sprintf("%d-%d over %d games, winner: %s", r.Wins[0], r.Wins[1], len(r.Games), winner)
+}
+
+// Run plays a match between players. Decks are checked with CheckSide
+// against the registered Options.Decks before every game after the first.
+func Run(opts Options, players [2]Player) (*MatchResult, error) {
+	for p, d := range opts.Decks {
+		if d == nil {
+			return nil, fmt.Errorf("no deck registered for player %d", p)
+		}
+	}
+
+	rng := deck.NewRand(opts.Seed)
+	result := &MatchResult{Seed: opts.Seed, Winner: Draw}
+
+	chooser := rng.Intn(0, 1)
+	current := [2]*deck.Deck{opts.Decks[0].Clone(), opts.Decks[1].Clone()}
+
+	for game := 1; game <= MaxGames; game++ {
+		if game > 1 {
+			for p := range players {
+				sided, err := players[p].Side(game, opts.Decks[p], current[p])
+				if err != nil {
+					return result, fmt.Errorf("player %d failed to side for game %d: %w", p, game, err)
+				}
+				if err := deck.CheckSide(opts.Decks[p], sided, opts.Format); err != nil {
+					return result, fmt.Errorf("player %d side deck for game %d: %w", p, game, err)
+				}
+				current[p] = sided.Clone()
+			}
+		}
+
+		first := 1 - chooser
+		if players[chooser].GoFirst(game) {
+			first = chooser
+		}
+
+		g := GameResult{
+			Game:  game,
+			Seed:  [4]uint64{rng.Uint64(), rng.Uint64(), rng.Uint64(), rng.Uint64()},
+			First: first,
+			Decks: [2]*deck.Deck{current[0].Clone(), current[1].Clone()},
+		}
+		if err := play(&g, opts, players); err != nil {
+			return result, fmt.Errorf("game %d: %w", game, err)
+		}
+		result.Games = append(result.Games, g)
+
+		// After a draw the same player chooses again.
+		if g.Winner != Draw {
+			result.Wins[g.Winner]++
+			chooser = 1 - g.Winner
+			if result.Wins[g.Winner] == WinsNeeded {
+				break
+			}
+		}
+	}
+
+	switch {
+	case result.Wins[0] > result.Wins[1]:
+		result.Winner = 0
+	case result.Wins[1] > result.Wins[0]:
+		result.Winner = 1
+	}
+	return result, nil
+}
+
+// play runs one game to completion and fills in Winner, Reason and Turns.
+// The core's team 0 always moves first, so the first player sits at team 0.
+func play(g *GameResult, opts Options, players [2]Player) error {
+	seat := [2]int{g.First, 1 - g.First} // team -> player
+
+	duelOpts := opts.Duel
+	duelOpts.Seed = g.Seed
+	duel, err := bridge.NewDuel(duelOpts)
+	if err != nil {
+		return err
+	}
+	defer duel.Close()
+
+	for team, p := range seat {
+		g.Decks[p].AddTo(duel, uint8(team))
+	}
+	duel.Start()
+
+	for {
+		status, msgs, err := duel.Step()
+		if err != nil {
+			return err
+		}
+
+		var req *duelpb.Msg_Request
+		for _, b := range msgs {
+			var m duelpb.Msg
+			if err := proto.Unmarshal(b, &m); err != nil {
+				return fmt.Errorf("failed to decode message: %w", err)
+			}
+			if opts.OnMessage != nil {
+				opts.OnMessage(g.Game, &m)
+			}
+
+			if r := m.GetRequest(); r != nil {
+				req = r
+			}
+			ev := m.GetEvent()
+			if _, ok := ev.GetT().(*duelpb.Msg_Event_NextTurn); ok {
+				g.Turns++
+			}
+			if fin := ev.GetFinish(); fin != nil {
+				g.Reason = fin.GetWinReason()
+				g.Winner = Draw
+				if !fin.GetDraw() {
+					g.Winner = seat[fin.GetWinner()&1]
+				}
+				return nil
+			}
+		}
+
+		switch status {
+		case bridge.DuelStatusEnd:
+			return fmt.Errorf("duel ended without a result")
+		case bridge.DuelStatusAwaiting:
+			if req == nil {
+				return fmt.Errorf("duel is awaiting an answer but sent no request")
+			}
+			p := seat[req.GetReplier()&1]
+			ans, err := players[p].Respond(req)
+			if err != nil {
+				return fmt.Errorf("player %d failed to respond: %w", p, err)
+			}
+			if err := duel.SendAnswer(ans); err != nil {
+				return err
+			}
+		}
+	}
+}
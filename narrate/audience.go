@@ -0,0 +1,111 @@
+package narrate
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/spb8026/ygo-visualizer/carddb"
+	"github.com/spb8026/ygo-visualizer/fog"
+	"github.com/spb8026/ygo-visualizer/state"
+	duelpb "github.com/spb8026/ygo-visualizer/ygopenpb"
+)
+
+// Audience narrates one duel to several viewers at once. Each viewer has
+// their own language and card database and only hears about what fog
+// lets them see: a card the opponent draws stays "a face-down card".
+type Audience struct {
+	seats map[fog.Viewer]*seat
+}
+
+type seat struct {
+	filter   *fog.Filter
+	narrator *Narrator
+}
+
+func NewAudience() *Audience {
+	return &Audience{seats: make(map[fog.Viewer]*seat)}
+}
+
+// Add seats v, narrating in lang with card text from db. It must be called
+// before the first message.
+func (a *Audience) Add(v fog.Viewer, db carddb.Source, lang string, verbosity Verbosity) error {
+	if _, ok := a.seats[v]; ok {
+		return fmt.Errorf("viewer %s is already seated", v)
+	}
+	n, err := NewLocalized(db, lang, verbosity)
+	if err != nil {
+		return err
+	}
+	a.seats[v] = &seat{filter: fog.NewFilter(v), narrator: n}
+	return nil
+}
+
+// Viewers returns the seated viewers in order.
+func (a *Audience) Viewers() []fog.Viewer {
+	var out []fog.Viewer
+	for v := range a.seats {
+		out = append(out, v)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// Board returns the board as v sees it, or nil if v is not seated.
+func (a *Audience) Board(v fog.Viewer) *state.Board {
+	if s, ok := a.seats[v]; ok {
+		return s.filter.Board()
+	}
+	return nil
+}
+
+// Catalog returns the catalog v is narrated in, or nil if v is not seated.
+func (a *Audience) Catalog(v fog.Viewer) *Catalog {
+	if s, ok := a.seats[v]; ok {
+		return s.narrator.Catalog()
+	}
+	return nil
+}
+
+// Told is what one viewer learns from a message: the message as fog lets
+// them see it, and its narration.
+type Told struct {
+	Msg   *duelpb.Msg
+	Lines []string
+}
+
+// Tell feeds one global message to every viewer and returns what each of
+// them learns. Viewers the message is hidden from get no entry.
+func (a *Audience) Tell(m *duelpb.Msg) (map[fog.Viewer]Told, error) {
+	out := make(map[fog.Viewer]Told)
+	for v, s := range a.seats {
+		seen, err := s.filter.Apply(m)
+		if err != nil {
+			return nil, err
+		}
+		if seen == nil {
+			continue
+		}
+		lines, err := s.narrator.Narrate(seen)
+		if err != nil {
+			return nil, fmt.Errorf("%s narration: %w", v, err)
+		}
+		out[v] = Told{Msg: seen, Lines: lines}
+	}
+	return out, nil
+}
+
+// Narrate feeds one global message to every viewer and returns what each
+// of them is told. Viewers told nothing get no entry.
+func (a *Audience) Narrate(m *duelpb.Msg) (map[fog.Viewer][]string, error) {
+	told, err := a.Tell(m)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[fog.Viewer][]string)
+	for v, t := range told {
+		if len(t.Lines) > 0 {
+			out[v] = t.Lines
+		}
+	}
+	return out, nil
+}
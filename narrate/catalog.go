@@ -0,0 +1,134 @@
+package narrate
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// DefaultLanguage is the catalog every other language falls back to.
+const DefaultLanguage = "en"
+
+//go:embed locales/*.json
+var locales embed.FS
+
+// Catalog holds the narration templates of one language. Templates use
+// positional placeholders {0}, {1}, ... so translations can reorder them.
+// Keys missing from a catalog are looked up in its fallback.
+type Catalog struct {
+	Language string
+	messages map[string]string
+	fallback *Catalog
+}
+
+// Languages lists the embedded catalogs.
+func Languages() []string {
+	entries, err := locales.ReadDir("locales")
+	if err != nil {
+		return nil
+	}
+	var langs []string
+	for _, e := range entries {
+		langs = append(langs, strings.TrimSuffix(e.Name(), ".json"))
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// LoadCatalog returns the embedded catalog for lang, backed by English.
+func LoadCatalog(lang string) (*Catalog, error) {
+	if lang == "" {
+		lang = DefaultLanguage
+	}
+	c, err := readCatalog(lang)
+	if err != nil {
+		return nil, err
+	}
+	if lang != DefaultLanguage {
+		if c.fallback, err = readCatalog(DefaultLanguage); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func readCatalog(lang string) (*Catalog, error) {
+	b, err := locales.ReadFile(path.Join("locales", lang+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("no narration catalog for language %q (have %s)", lang, strings.Join(Languages(), ", "))
+	}
+	c := &Catalog{Language: lang}
+	if err := json.Unmarshal(b, &c.messages); err != nil {
+		return nil, fmt.Errorf("failed to parse %s catalog: %w", lang, err)
+	}
+	return c, nil
+}
+
+// english is the catalog used by New and the package-level helpers.
+var english = mustCatalog(DefaultLanguage)
+
+func mustCatalog(lang string) *Catalog {
+	c, err := LoadCatalog(lang)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func (c *Catalog) lookup(key string) (string, bool) {
+	for cat := c; cat != nil; cat = cat.fallback {
+		if s, ok := cat.messages[key]; ok {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// Format fills the template for key with args. An unknown key renders as
+// the key itself so gaps show up in output instead of failing.
+func (c *Catalog) Format(key string, args ...any) string {
+	s, ok := c.lookup(key)
+	if !ok {
+		return key
+	}
+	if len(args) == 0 {
+		return s
+	}
+	pairs := make([]string, 0, 2*len(args))
+	for i, a := range args {
+		pairs = append(pairs, fmt.Sprintf("{%d}", i), fmt.Sprint(a))
+	}
+	return strings.NewReplacer(pairs...).Replace(s)
+}
+
+// Count formats n of something with the key's ".one" form when n is 1,
+// for languages that have one. The singular is only taken from the catalog
+// that defines key, so a language without plurals never borrows English's.
+func (c *Catalog) Count(key string, n int) string {
+	for cat := c; n == 1 && cat != nil; cat = cat.fallback {
+		if _, ok := cat.messages[key]; !ok {
+			continue
+		}
+		if _, ok := cat.messages[key+".one"]; ok {
+			return c.Format(key+".one", n)
+		}
+		break
+	}
+	return c.Format(key, n)
+}
+
+// List joins items with the catalog's separators.
+func (c *Catalog) List(items []string) string {
+	switch len(items) {
+	case 0:
+		return ""
+	case 1:
+		return items[0]
+	}
+	sep := c.Format("list.separator")
+	last := c.Format("list.last")
+	return strings.Join(items[:len(items)-1], sep) + last + items[len(items)-1]
+}
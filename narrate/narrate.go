@@ -0,0 +1,511 @@
+// Package narrate turns the duel message stream into readable sentences
+// for logs and review. Wording comes from per-language message catalogs.
+package narrate
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/spb8026/ygo-visualizer/carddb"
+	"github.com/spb8026/ygo-visualizer/state"
+	duelpb "github.com/spb8026/ygo-visualizer/ygopenpb"
+)
+
+// Verbosity selects how much of the stream is narrated.
+type Verbosity int
+
+const (
+	// Quiet: turns, summons and sets, attacks, LP changes and the result.
+	Quiet Verbosity = iota
+	// Normal adds draws, phases, chains and cards changing location.
+	Normal
+	// Verbose adds shuffles, pile resizes, hints and pending requests.
+	Verbose
+)
+
+// ParseVerbosity accepts "quiet", "normal" or "verbose".
+func ParseVerbosity(s string) (Verbosity, error) {
+	switch strings.ToLower(s) {
+	case "quiet":
+		return Quiet, nil
+	case "normal", "":
+		return Normal, nil
+	case "verbose":
+		return Verbose, nil
+	}
+	return Normal, fmt.Errorf("unknown verbosity %q (want quiet, normal or verbose)", s)
+}
+
+// Narrator keeps the board needed to name cards as they move.
+type Narrator struct {
+	db        carddb.Source
+	cat       *Catalog
+	board     *state.Board
+	verbosity Verbosity
+
+	// attacking is set between an attack declaration and the next chain or
+	// phase, so damage in between is reported as battle damage.
+	attacking bool
+}
+
+// New returns an English narrator. db may be nil, in which case cards are
+// named by code.
+func New(db carddb.Source, v Verbosity) *Narrator {
+	return &Narrator{db: db, cat: english, board: state.New(), verbosity: v}
+}
+
+// NewLocalized returns a narrator for lang. For translated card names,
+// pass a carddb.Layered source built from that language's cdb.
+func NewLocalized(db carddb.Source, lang string, v Verbosity) (*Narrator, error) {
+	cat, err := LoadCatalog(lang)
+	if err != nil {
+		return nil, err
+	}
+	n := New(db, v)
+	n.cat = cat
+	return n, nil
+}
+
+// Catalog returns the catalog the narrator writes with.
+func (n *Narrator) Catalog() *Catalog {
+	return n.cat
+}
+
+// Board returns the board the narrator maintains.
+func (n *Narrator) Board() *state.Board {
+	return n.board
+}
+
+// Narrate applies m to the narrator's board and returns its sentences,
+// possibly none.
+func (n *Narrator) Narrate(m *duelpb.Msg) ([]string, error) {
+	if req := m.GetRequest(); req != nil {
+		if err := n.board.Apply(m); err != nil {
+			return nil, err
+		}
+		if n.verbosity >= Verbose {
+			return []string{n.say("request.waiting", n.player(int(req.GetReplier())), n.say(requestKey(req)))}, nil
+		}
+		return nil, nil
+	}
+
+	ev := m.GetEvent()
+	pre := n.capture(ev)
+	if err := n.board.Apply(m); err != nil {
+		return nil, err
+	}
+	lines := n.describe(ev, pre)
+	for i, line := range lines {
+		lines[i] = capitalize(line)
+	}
+	return lines, nil
+}
+
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+func (n *Narrator) say(key string, args ...any) string {
+	return n.cat.Format(key, args...)
+}
+
+func (n *Narrator) player(con int) string {
+	return n.cat.PlayerName(con)
+}
+
+// before holds what an event destroys: the cards at its old places and
+// the life points it changes.
+type before struct {
+	cards []*state.Card
+	lp    uint32
+	top   *state.Link
+}
+
+func (n *Narrator) capture(ev *duelpb.Msg_Event) before {
+	var pre before
+	pre.top = n.board.Chains.Top()
+	switch {
+	case ev.GetCard().GetMove() != nil:
+		for _, op := range ev.GetCard().GetMove().GetOps() {
+			pre.cards = append(pre.cards, n.board.At(op.GetOldPlace()))
+		}
+	case ev.GetCard().GetRemove() != nil:
+		for _, place := range ev.GetCard().GetRemove().GetPlaces() {
+			if c := n.board.At(place); c != nil {
+				cp := *c
+				pre.cards = append(pre.cards, &cp)
+			}
+		}
+	case ev.GetLp() != nil:
+		if con := ev.GetLp().GetController(); con == 0 || con == 1 {
+			pre.lp = n.board.Players[con].LP
+		}
+	}
+	return pre
+}
+
+func (n *Narrator) describe(ev *duelpb.Msg_Event, pre before) []string {
+	b := n.board
+	switch t := ev.GetT().(type) {
+	case *duelpb.Msg_Event_NextTurn:
+		n.attacking = false
+		return []string{n.say("turn", b.Turn, n.player(b.TurnPlayer))}
+
+	case *duelpb.Msg_Event_NextPhase:
+		n.attacking = false
+		if n.verbosity >= Normal {
+			return []string{n.say("phase.enter", n.player(b.TurnPlayer), n.cat.PhaseName(t.NextPhase))}
+		}
+
+	case *duelpb.Msg_Event_Card_:
+		return n.describeCard(t.Card, pre)
+
+	case *duelpb.Msg_Event_Lp:
+		return []string{n.describeLP(t.Lp, pre.lp)}
+
+	case *duelpb.Msg_Event_ChainStack_:
+		n.attacking = false
+		if n.verbosity < Normal {
+			return nil
+		}
+		if t.ChainStack.GetPush() != nil {
+			l := b.Chains.Top()
+			if d := n.effectText(l.Effect); d != "" {
+				return []string{n.say("chain.activate_text", n.player(l.Controller), n.cardName(l.Code), l.Number, d)}
+			}
+			return []string{n.say("chain.activate", n.player(l.Controller), n.cardName(l.Code), l.Number)}
+		}
+		if pre.top != nil {
+			return []string{n.say("chain."+pre.top.Outcome.String(), pre.top.Number, n.cardName(pre.top.Code))}
+		}
+
+	case *duelpb.Msg_Event_Meta_:
+		return n.describeMeta(t.Meta)
+
+	case *duelpb.Msg_Event_Finish_:
+		if t.Finish.GetDraw() {
+			return []string{n.say("finish.draw")}
+		}
+		return []string{n.say("finish.win", n.player(int(t.Finish.GetWinner())))}
+
+	case *duelpb.Msg_Event_Result_:
+		if n.verbosity >= Normal {
+			return []string{n.describeResult(t.Result)}
+		}
+
+	case *duelpb.Msg_Event_Pile_:
+		if n.verbosity >= Verbose {
+			return []string{n.say("pile.resize")}
+		}
+	}
+	return nil
+}
+
+func (n *Narrator) describeCard(ev *duelpb.Msg_Event_Card, pre before) []string {
+	b := n.board
+	reason := ev.GetReason()
+
+	switch t := ev.GetT().(type) {
+	case *duelpb.Msg_Event_Card_Move_:
+		ops := t.Move.GetOps()
+
+		// Group draws into one sentence.
+		if reason&REASON_DRAW != 0 && len(ops) > 0 {
+			if n.verbosity < Normal {
+				return nil
+			}
+			var names []string
+			for _, op := range ops {
+				if c := b.At(op.GetNewPlace()); c != nil && c.Code != 0 {
+					names = append(names, n.cardName(c.Code))
+				}
+			}
+			who := n.player(int(ops[0].GetNewPlace().GetCon()))
+			count := n.cat.Count("count.cards", len(ops))
+			if len(names) == len(ops) {
+				return []string{n.say("draw.named", who, count, n.cat.List(names))}
+			}
+			return []string{n.say("draw", who, count)}
+		}
+
+		var out []string
+		for i, op := range ops {
+			if s := n.describeMove(op.GetOldPlace(), op.GetNewPlace(), pre.cards[i], reason); s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+
+	case *duelpb.Msg_Event_Card_Add_:
+		if n.verbosity < Normal {
+			return nil
+		}
+		var out []string
+		for _, place := range t.Add.GetPlaces() {
+			if place.GetLoc() == state.LOC_MZONE {
+				out = append(out, n.say("card.placed", n.describeCardAt(place), n.cat.ZoneName(place, b.SeparatePZones), n.player(int(place.GetCon()))))
+			}
+		}
+		return out
+
+	case *duelpb.Msg_Event_Card_Remove_:
+		if n.verbosity < Normal {
+			return nil
+		}
+		var out []string
+		for _, c := range pre.cards {
+			out = append(out, n.say("card.removed", n.cardLabel(c)))
+		}
+		return out
+
+	case *duelpb.Msg_Event_Card_Exchange_:
+		if n.verbosity >= Normal {
+			return []string{n.say("card.exchange", n.cat.Count("count.cards", 2*len(t.Exchange.GetOps())))}
+		}
+
+	case *duelpb.Msg_Event_Card_Shuffle_:
+		if n.verbosity >= Verbose && len(t.Shuffle.GetPreviousPlaces()) > 0 {
+			p := t.Shuffle.GetPreviousPlaces()[0]
+			return []string{n.say("shuffle", n.player(int(p.GetCon())), n.cat.pileName(p.GetLoc()))}
+		}
+	}
+	return nil
+}
+
+func (n *Narrator) describeMove(from, to *duelpb.Place, card *state.Card, reason uint64) string {
+	b := n.board
+	if card == nil {
+		card = b.At(to)
+	}
+	name := n.cardLabel(card)
+	who := n.player(int(to.GetCon()))
+	zone := n.cat.ZoneName(to, b.SeparatePZones)
+	faceDown := card != nil && card.Position&state.POS_FACEDOWN != 0
+
+	switch {
+	case to.GetOseq() >= 0:
+		if n.verbosity >= Normal {
+			return n.say("move.attach", name, n.describeCardAt(&duelpb.Place{Con: to.GetCon(), Loc: state.LOC_MZONE, Seq: to.GetSeq(), Oseq: -1}))
+		}
+	case to.GetLoc() == state.LOC_MZONE && from.GetLoc() == state.LOC_MZONE:
+		if from.GetCon() != to.GetCon() {
+			return n.say("move.control", who, name)
+		}
+		if n.verbosity >= Normal {
+			return n.say("move.zone", name, zone)
+		}
+	case to.GetLoc() == state.LOC_MZONE:
+		if faceDown && reason&REASON_SPSUMMON == 0 {
+			return n.say("set.monster", who, zone)
+		}
+		return n.say(summonKey(reason), who, name, zone)
+	case to.GetLoc() == state.LOC_SZONE:
+		if faceDown {
+			return n.say("set.card", who, zone)
+		}
+		return n.say("move.place", who, name, zone)
+	case n.verbosity < Normal:
+	case to.GetLoc() == state.LOC_GRAVE:
+		switch {
+		case reason&REASON_DISCARD != 0:
+			return n.say("move.discard", n.player(int(from.GetCon())), name)
+		case reason&REASON_DESTROY != 0 && reason&REASON_BATTLE != 0:
+			return n.say("move.destroyed_battle", name)
+		case reason&REASON_DESTROY != 0:
+			return n.say("move.destroyed", name)
+		case reason&REASON_RELEASE != 0:
+			return n.say("move.tributed", name)
+		}
+		return n.say("move.grave", name)
+	case to.GetLoc() == state.LOC_REMOVED:
+		return n.say("move.banished", name)
+	case to.GetLoc() == state.LOC_HAND:
+		return n.say("move.hand", name, who)
+	case to.GetLoc() == state.LOC_DECK:
+		return n.say("move.deck", name, who)
+	case to.GetLoc() == state.LOC_EXTRA:
+		return n.say("move.extra", name, who)
+	}
+	return ""
+}
+
+func (n *Narrator) describeLP(ev *duelpb.Msg_Event_LP, old uint32) string {
+	con := int(ev.GetController())
+	who := n.player(con)
+	lp := n.board.Players[con].LP
+
+	switch t := ev.GetT().(type) {
+	case *duelpb.Msg_Event_LP_Damage:
+		if n.attacking {
+			return n.say("lp.battle_damage", who, t.Damage, old, lp)
+		}
+		return n.say("lp.effect_damage", who, t.Damage, old, lp)
+	case *duelpb.Msg_Event_LP_Pay:
+		return n.say("lp.pay", who, t.Pay, old, lp)
+	case *duelpb.Msg_Event_LP_Recover:
+		return n.say("lp.recover", who, t.Recover, old, lp)
+	}
+	return n.say("lp.become", who, lp, old)
+}
+
+func (n *Narrator) describeMeta(ev *duelpb.Msg_Event_Meta) []string {
+	b := n.board
+	switch t := ev.GetT().(type) {
+	case *duelpb.Msg_Event_Meta_Attack_:
+		n.attacking = true
+		attacker := n.describeCardAt(t.Attack.GetAttacker())
+		target := t.Attack.GetAttackTarget()
+		if target == nil || b.At(target) == nil {
+			return []string{n.say("attack.direct", attacker)}
+		}
+		return []string{n.say("attack", attacker, n.describeCardAt(target))}
+
+	case *duelpb.Msg_Event_Meta_ChainStatus:
+		if n.verbosity < Normal {
+			return nil
+		}
+		top := b.Chains.Top()
+		if top == nil {
+			return nil
+		}
+		switch t.ChainStatus {
+		case duelpb.ChainStatus_CHAIN_STATUS_ACT_NEGATED:
+			return []string{n.say("negate.activation", n.cardName(top.Code))}
+		case duelpb.ChainStatus_CHAIN_STATUS_EFF_NEGATED:
+			return []string{n.say("negate.effect", n.cardName(top.Code))}
+		}
+
+	case *duelpb.Msg_Event_Meta_Confirm_:
+		if n.verbosity < Normal || len(t.Confirm.GetPlaces()) == 0 {
+			return nil
+		}
+		var names []string
+		for _, place := range t.Confirm.GetPlaces() {
+			names = append(names, n.describeCardAt(place))
+		}
+		return []string{n.say("reveal", n.player(int(t.Confirm.GetPlaces()[0].GetCon())), n.cat.List(names))}
+
+	case *duelpb.Msg_Event_Meta_FlashCard_:
+		if n.verbosity >= Normal {
+			return []string{n.say("declare", n.cardName(t.FlashCard.GetCode()))}
+		}
+
+	case *duelpb.Msg_Event_Meta_ShufflePile_:
+		if n.verbosity >= Verbose {
+			var out []string
+			for _, place := range t.ShufflePile.GetPlaces() {
+				out = append(out, n.say("shuffle", n.player(int(place.GetCon())), n.cat.pileName(place.GetLoc())))
+			}
+			return out
+		}
+
+	case *duelpb.Msg_Event_Meta_Description_:
+		if n.verbosity >= Verbose {
+			if e := t.Description.GetAdd(); e != nil {
+				return []string{n.say("hint", n.player(int(t.Description.GetCon())), n.effectText(e))}
+			}
+		}
+	}
+	return nil
+}
+
+func (n *Narrator) describeResult(ev *duelpb.Msg_Event_Result) string {
+	switch t := ev.GetT().(type) {
+	case *duelpb.Msg_Event_Result_Coin_:
+		var faces []string
+		for _, heads := range t.Coin.GetValues() {
+			if heads {
+				faces = append(faces, n.say("coin.heads"))
+			} else {
+				faces = append(faces, n.say("coin.tails"))
+			}
+		}
+		return n.say("coin", n.player(int(t.Coin.GetActor())), n.cat.List(faces))
+	case *duelpb.Msg_Event_Result_Dice_:
+		var rolls []string
+		for _, v := range t.Dice.GetValues() {
+			rolls = append(rolls, fmt.Sprint(v))
+		}
+		return n.say("dice", n.player(int(t.Dice.GetActor())), n.cat.List(rolls))
+	}
+	return n.say("rps")
+}
+
+func requestKey(req *duelpb.Msg_Request) string {
+	switch req.GetT().(type) {
+	case *duelpb.Msg_Request_SelectIdle_:
+		return "request.idle"
+	case *duelpb.Msg_Request_SelectToChain_:
+		return "request.chain"
+	case *duelpb.Msg_Request_SelectCard_:
+		return "request.card"
+	case *duelpb.Msg_Request_SelectZone_:
+		return "request.zone"
+	case *duelpb.Msg_Request_SelectPosition_:
+		return "request.position"
+	case *duelpb.Msg_Request_SelectYesNo_:
+		return "request.yes_no"
+	case *duelpb.Msg_Request_SelectEffect_:
+		return "request.effect"
+	}
+	return "request.other"
+}
+
+/*
+   Card naming
+*/
+
+// cardName returns the card's name from the database, or its code.
+func (n *Narrator) cardName(code uint32) string {
+	if code == 0 {
+		return n.say("card.face_down")
+	}
+	if n.db != nil {
+		if text, err := n.db.GetText(code); err == nil && text != nil && text.Name != "" {
+			return text.Name
+		}
+	}
+	return n.say("card.code", code)
+}
+
+// cardLabel names a card and, for monsters, adds its current ATK/DEF.
+func (n *Narrator) cardLabel(c *state.Card) string {
+	if c == nil || c.Code == 0 {
+		return n.say("card.face_down")
+	}
+	name := n.cardName(c.Code)
+	if n.db == nil {
+		return name
+	}
+	data, err := n.db.GetCard(c.Code)
+	if err != nil || data == nil || !data.IsMonster() {
+		return name
+	}
+	atk, def := c.Atk, c.Def
+	if atk == 0 && def == 0 {
+		atk, def = data.Attack, data.Defense
+	}
+	if data.Type&carddb.TYPE_LINK != 0 {
+		return n.say("card.stats_link", name, atk, data.Level)
+	}
+	return n.say("card.stats", name, atk, def)
+}
+
+func (n *Narrator) describeCardAt(place *duelpb.Place) string {
+	return n.cardLabel(n.board.At(place))
+}
+
+// effectText returns the description string of an effect, or "".
+func (n *Narrator) effectText(e *duelpb.Effect) string {
+	if n.db == nil || e == nil || e.GetCode() == 0 {
+		return ""
+	}
+	text, err := n.db.GetText(e.GetCode())
+	if err != nil || text == nil || int(e.GetIndex()) >= len(text.Strings) {
+		return ""
+	}
+	return text.Strings[e.GetIndex()]
+}
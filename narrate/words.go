@@ -0,0 +1,160 @@
+package narrate
+
+import (
+	"fmt"
+
+	"github.com/spb8026/ygo-visualizer/state"
+	duelpb "github.com/spb8026/ygo-visualizer/ygopenpb"
+)
+
+// REASON_* bits of Msg_Event_Card.Reason, as set by the core.
+const (
+	REASON_DESTROY  = 0x1
+	REASON_RELEASE  = 0x2
+	REASON_MATERIAL = 0x8
+	REASON_SUMMON   = 0x10
+	REASON_BATTLE   = 0x20
+	REASON_EFFECT   = 0x40
+	REASON_COST     = 0x80
+	REASON_SPSUMMON = 0x800
+	REASON_FLIP     = 0x2000
+	REASON_DISCARD  = 0x4000
+	REASON_RETURN   = 0x20000
+	REASON_DRAW     = 0x2000000
+	REASON_REVEAL   = 0x8000000
+	REASON_LINK     = 0x10000000
+	REASON_XYZ      = 0x200000
+	REASON_SYNCHRO  = 0x80000
+	REASON_FUSION   = 0x40000
+	REASON_RITUAL   = 0x100000
+)
+
+// PHASE_* values of Msg_Event.NextPhase.
+const (
+	PHASE_DRAW         = 0x1
+	PHASE_STANDBY      = 0x2
+	PHASE_MAIN1        = 0x4
+	PHASE_BATTLE_START = 0x8
+	PHASE_BATTLE_STEP  = 0x10
+	PHASE_DAMAGE       = 0x20
+	PHASE_DAMAGE_CAL   = 0x40
+	PHASE_BATTLE       = 0x80
+	PHASE_MAIN2        = 0x100
+	PHASE_END          = 0x200
+)
+
+// PlayerName numbers players from 1 for readers, in English.
+func PlayerName(con int) string {
+	return english.PlayerName(con)
+}
+
+// PhaseName names a PHASE_* value, in English.
+func PhaseName(phase uint32) string {
+	return english.PhaseName(phase)
+}
+
+// ZoneName names a place the way the rulebook does, in English.
+func ZoneName(place *duelpb.Place, separatePZones bool) string {
+	return english.ZoneName(place, separatePZones)
+}
+
+func (c *Catalog) PlayerName(con int) string {
+	return c.Format("player", con+1)
+}
+
+func (c *Catalog) PhaseName(phase uint32) string {
+	switch phase {
+	case PHASE_DRAW:
+		return c.Format("phase.draw")
+	case PHASE_STANDBY:
+		return c.Format("phase.standby")
+	case PHASE_MAIN1:
+		return c.Format("phase.main1")
+	case PHASE_BATTLE_START, PHASE_BATTLE_STEP, PHASE_BATTLE:
+		return c.Format("phase.battle")
+	case PHASE_DAMAGE:
+		return c.Format("phase.damage")
+	case PHASE_DAMAGE_CAL:
+		return c.Format("phase.damage_calculation")
+	case PHASE_MAIN2:
+		return c.Format("phase.main2")
+	case PHASE_END:
+		return c.Format("phase.end")
+	}
+	return c.Format("phase.unknown", fmt.Sprintf("0x%x", phase))
+}
+
+func (c *Catalog) ZoneName(place *duelpb.Place, separatePZones bool) string {
+	seq := place.GetSeq()
+	if place.GetOseq() >= 0 {
+		return c.Format("zone.overlay", c.ZoneName(&duelpb.Place{Con: place.GetCon(), Loc: state.LOC_MZONE, Seq: seq, Oseq: -1}, separatePZones))
+	}
+	switch place.GetLoc() {
+	case state.LOC_MZONE:
+		switch seq {
+		case state.SeqEMZLeft:
+			return c.Format("zone.emz_left")
+		case state.SeqEMZRight:
+			return c.Format("zone.emz_right")
+		}
+		return c.Format("zone.monster", seq+1)
+	case state.LOC_SZONE:
+		switch {
+		case seq == state.SeqFieldZone:
+			return c.Format("zone.field")
+		case separatePZones && seq == state.SeqPZoneLeft:
+			return c.Format("zone.pendulum_left")
+		case separatePZones && seq == state.SeqPZoneRight:
+			return c.Format("zone.pendulum_right")
+		}
+		return c.Format("zone.spell", seq+1)
+	case state.LOC_DECK:
+		return c.Format("zone.deck")
+	case state.LOC_HAND:
+		return c.Format("zone.hand")
+	case state.LOC_GRAVE:
+		return c.Format("zone.grave")
+	case state.LOC_REMOVED:
+		return c.Format("zone.removed")
+	case state.LOC_EXTRA:
+		return c.Format("zone.extra")
+	}
+	return state.LocationName(place.GetLoc())
+}
+
+func (c *Catalog) pileName(loc uint32) string {
+	switch loc {
+	case state.LOC_DECK:
+		return c.Format("pile.deck")
+	case state.LOC_HAND:
+		return c.Format("pile.hand")
+	case state.LOC_EXTRA:
+		return c.Format("pile.extra")
+	case state.LOC_GRAVE:
+		return c.Format("pile.grave")
+	case state.LOC_REMOVED:
+		return c.Format("pile.removed")
+	}
+	return state.LocationName(loc)
+}
+
+// summonKey picks the summon sentence for a move's reason.
+func summonKey(reason uint64) string {
+	switch {
+	case reason&REASON_LINK != 0:
+		return "summon.link"
+	case reason&REASON_XYZ != 0:
+		return "summon.xyz"
+	case reason&REASON_SYNCHRO != 0:
+		return "summon.synchro"
+	case reason&REASON_FUSION != 0:
+		return "summon.fusion"
+	case reason&REASON_RITUAL != 0:
+		return "summon.ritual"
+	case reason&REASON_SPSUMMON != 0:
+		return "summon.special"
+	case reason&REASON_FLIP != 0:
+		return "summon.flip"
+	}
+	return "summon.normal"
+}
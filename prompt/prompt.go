@@ -0,0 +1,158 @@
+// Package prompt turns core requests into choices a person can make: a
+// question, a list of options naming the cards involved, and how many of
+// them to pick. Front ends only collect a selection; Prompt checks it and
+// builds the answer the core expects. The terminal UI and the live server
+// share it.
+package prompt
+
+import (
+	"fmt"
+
+	"github.com/spb8026/ygo-visualizer/carddb"
+	duelpb "github.com/spb8026/ygo-visualizer/ygopenpb"
+)
+
+type Mode int
+
+const (
+	// PickOne answers with a single option.
+	PickOne Mode = iota
+	// PickMany marks between Min and Max options.
+	PickMany
+	// PickOrder orders every option.
+	PickOrder
+	// PickAmounts sets an amount of up to its Limit on each option, Max in
+	// total.
+	PickAmounts
+	// DeclareCode asks for a card code rather than an option.
+	DeclareCode
+)
+
+var modeNames = [...]string{"one", "many", "order", "amounts", "code"}
+
+func (m Mode) String() string {
+	if int(m) < len(modeNames) {
+		return modeNames[m]
+	}
+	return fmt.Sprintf("mode %d", int(m))
+}
+
+func (m Mode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+type Option struct {
+	Label string `json:"label"`
+	// Code is the card the option is about, or 0.
+	Code uint32 `json:"code,omitempty"`
+	// Limit is the largest amount of a PickAmounts option.
+	Limit int `json:"limit,omitempty"`
+}
+
+// Prompt is one request laid out for a person.
+type Prompt struct {
+	Title    string   `json:"title"`
+	Question string   `json:"question"`
+	Mode     Mode     `json:"mode"`
+	Options  []Option `json:"options,omitempty"`
+	// Min and Max bound the number of PickMany options; Max is also the
+	// total of a PickAmounts selection.
+	Min int `json:"min,omitempty"`
+	Max int `json:"max,omitempty"`
+	// CanCancel reports whether Cancel answers the request.
+	CanCancel bool `json:"canCancel,omitempty"`
+
+	// check validates a complete selection beyond Min and Max.
+	check func(sel []int) error
+	// done builds the answer from a selection Answer accepted.
+	done func(sel []int) *duelpb.Answer
+	// escape is the answer to Cancel.
+	escape *duelpb.Answer
+	db     carddb.Source
+}
+
+// Answer builds the answer to a selection: the chosen option for PickOne,
+// the marked options for PickMany, every option in its new order for
+// PickOrder, and each option's amount for PickAmounts. An error says what
+// is wrong with the selection, in words fit to show the player.
+func (p *Prompt) Answer(sel []int) (*duelpb.Answer, error) {
+	if p.Mode == DeclareCode {
+		return nil, fmt.Errorf("declare a card code")
+	}
+	if len(p.Options) == 0 {
+		return nil, fmt.Errorf("nothing to choose")
+	}
+	if p.Mode != PickAmounts {
+		seen := make(map[int]bool)
+		for _, i := range sel {
+			if i < 0 || i >= len(p.Options) {
+				return nil, fmt.Errorf("no option %d", i+1)
+			}
+			if seen[i] {
+				return nil, fmt.Errorf("option %d is chosen twice", i+1)
+			}
+			seen[i] = true
+		}
+	}
+	switch p.Mode {
+	case PickOne:
+		if len(sel) != 1 {
+			return nil, fmt.Errorf("choose one option")
+		}
+	case PickMany:
+		if len(sel) < p.Min || len(sel) > p.Max {
+			if p.Min == p.Max {
+				return nil, fmt.Errorf("mark exactly %d", p.Min)
+			}
+			return nil, fmt.Errorf("mark between %d and %d", p.Min, p.Max)
+		}
+	case PickOrder:
+		if len(sel) != len(p.Options) {
+			return nil, fmt.Errorf("order every card, or skip")
+		}
+	case PickAmounts:
+		if len(sel) != len(p.Options) {
+			return nil, fmt.Errorf("give an amount for every card")
+		}
+		total := 0
+		for i, a := range sel {
+			if a < 0 || a > p.Options[i].Limit {
+				return nil, fmt.Errorf("%s can give 0 to %d", p.Options[i].Label, p.Options[i].Limit)
+			}
+			total += a
+		}
+		if total != p.Max {
+			return nil, fmt.Errorf("choose exactly %d in total", p.Max)
+		}
+	}
+	if p.check != nil {
+		if err := p.check(sel); err != nil {
+			return nil, err
+		}
+	}
+	return p.done(sel), nil
+}
+
+// Declare answers a DeclareCode prompt with a card code.
+func (p *Prompt) Declare(code uint32) (*duelpb.Answer, error) {
+	if p.Mode != DeclareCode {
+		return nil, fmt.Errorf("choose from the options")
+	}
+	if code == 0 {
+		return nil, fmt.Errorf("type a card code")
+	}
+	if p.db != nil {
+		if data, err := p.db.GetCard(code); err != nil || data == nil {
+			return nil, fmt.Errorf("no card with code %d", code)
+		}
+	}
+	return &duelpb.Answer{T: &duelpb.Answer_SelectCardCode{SelectCardCode: code}}, nil
+}
+
+// Cancel answers the request without choosing, where it allows that.
+func (p *Prompt) Cancel() (*duelpb.Answer, error) {
+	if p.escape == nil {
+		return nil, fmt.Errorf("this choice cannot be cancelled")
+	}
+	return p.escape, nil
+}
@@ -0,0 +1,547 @@
+package prompt
+
+import (
+	"fmt"
+
+	"github.com/spb8026/ygo-visualizer/carddb"
+	"github.com/spb8026/ygo-visualizer/narrate"
+	"github.com/spb8026/ygo-visualizer/render"
+	"github.com/spb8026/ygo-visualizer/state"
+	duelpb "github.com/spb8026/ygo-visualizer/ygopenpb"
+	"google.golang.org/protobuf/proto"
+)
+
+// namer labels the cards and places a request refers to, from the
+// answering player's view of the board.
+type namer struct {
+	board *state.Board
+	db    carddb.Source
+	cat   *narrate.Catalog
+}
+
+func (n namer) card(code uint32) string {
+	return render.CardName(code, n.db)
+}
+
+// codeAt returns code, or the code of the card at place when code is 0.
+func (n namer) codeAt(place *duelpb.Place, code uint32) uint32 {
+	if code == 0 && place != nil {
+		if c := n.board.At(place); c != nil {
+			code = c.Code
+		}
+	}
+	return code
+}
+
+// at labels a card by name and place: "Dark Magician · Main Monster Zone 3 · Player 1".
+func (n namer) at(place *duelpb.Place, code uint32) string {
+	name := n.card(n.codeAt(place, code))
+	if place == nil {
+		return name
+	}
+	return fmt.Sprintf("%s · %s · %s", name, n.cat.ZoneName(place, n.board.SeparatePZones), n.cat.PlayerName(int(place.GetCon())))
+}
+
+// effect returns the text of an effect description, or a generic label.
+func (n namer) effect(e *duelpb.Effect) string {
+	if n.db != nil && e != nil {
+		if text, err := n.db.GetText(e.GetCode()); err == nil && text != nil && int(e.GetIndex()) < len(text.Strings) && text.Strings[e.GetIndex()] != "" {
+			return text.Strings[e.GetIndex()]
+		}
+	}
+	return fmt.Sprintf("effect %d of %s", e.GetIndex()+1, n.card(e.GetCode()))
+}
+
+func indexes(sel []int) *duelpb.Answer_Indexes {
+	out := &duelpb.Answer_Indexes{}
+	for _, i := range sel {
+		out.Values = append(out.Values, uint32(i))
+	}
+	return out
+}
+
+func cardIndexes(sel []int) *duelpb.Answer {
+	return &duelpb.Answer{T: &duelpb.Answer_SelectCard_{SelectCard: &duelpb.Answer_SelectCard{
+		T: &duelpb.Answer_SelectCard_Indexes{Indexes: indexes(sel)},
+	}}}
+}
+
+var cardCancel = &duelpb.Answer{T: &duelpb.Answer_SelectCard_{SelectCard: &duelpb.Answer_SelectCard{
+	T: &duelpb.Answer_SelectCard_Cancel{Cancel: true},
+}}}
+
+var cardFinish = &duelpb.Answer{T: &duelpb.Answer_SelectCard_{SelectCard: &duelpb.Answer_SelectCard{
+	T: &duelpb.Answer_SelectCard_Finish{Finish: true},
+}}}
+
+// positionNames lists POS_* bits in the order they are offered.
+var positionNames = []struct {
+	flag uint32
+	name string
+}{
+	{state.POS_FACEUP_ATTACK, "Face-up Attack Position"},
+	{state.POS_FACEUP_DEFENSE, "Face-up Defense Position"},
+	{state.POS_FACEDOWN_DEFENSE, "Face-down Defense Position"},
+	{state.POS_FACEDOWN_ATTACK, "Face-down Attack Position"},
+}
+
+// For lays out req as the player answering it sees it: board is that
+// player's view of the duel, cat the language prompts are written in and
+// db, which may be nil, names the cards.
+func For(req *duelpb.Msg_Request, board *state.Board, db carddb.Source, cat *narrate.Catalog) (*Prompt, error) {
+	n := namer{board: board, db: db, cat: cat}
+	var p *Prompt
+	var err error
+	switch t := req.GetT().(type) {
+	case *duelpb.Msg_Request_SelectIdle_:
+		p = idlePrompt(t.SelectIdle, n)
+	case *duelpb.Msg_Request_SelectToChain_:
+		p = chainPrompt(t.SelectToChain, n)
+	case *duelpb.Msg_Request_SelectCard_:
+		p, err = cardPrompt(t.SelectCard, n)
+	case *duelpb.Msg_Request_SelectZone_:
+		p = zonePrompt(t.SelectZone, n)
+	case *duelpb.Msg_Request_SelectPosition_:
+		p = positionPrompt(t.SelectPosition, n)
+	case *duelpb.Msg_Request_SelectYesNo_:
+		p = yesNoPrompt(t.SelectYesNo, n)
+	case *duelpb.Msg_Request_SelectEffect_:
+		p = effectPrompt(t.SelectEffect, n)
+	case *duelpb.Msg_Request_SelectNumber_:
+		p = numberPrompt(t.SelectNumber)
+	case *duelpb.Msg_Request_SelectAttribute_:
+		p = attributePrompt(t.SelectAttribute)
+	case *duelpb.Msg_Request_SelectRace_:
+		p = racePrompt(t.SelectRace)
+	case *duelpb.Msg_Request_SelectCardCode_:
+		// The opcode filter is left to the core, which rejects codes that
+		// do not match it and asks again.
+		p = &Prompt{Title: "Declare a card", Question: "Declare a card name", Mode: DeclareCode, db: n.db}
+		if t.SelectCardCode.GetCount() > 1 {
+			p.Question = fmt.Sprintf("Declare %d card names, one at a time", t.SelectCardCode.GetCount())
+		}
+	case *duelpb.Msg_Request_SelectCounter_:
+		p = counterPrompt(t.SelectCounter, n)
+	case *duelpb.Msg_Request_Sort_:
+		p = sortPrompt(t.Sort, n)
+	case *duelpb.Msg_Request_SelectRockPaperScissors:
+		p = rpsPrompt()
+	default:
+		return nil, fmt.Errorf("unsupported request %T", req.GetT())
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.CanCancel = p.escape != nil
+	return p, nil
+}
+
+func idlePrompt(req *duelpb.Msg_Request_SelectIdle, n namer) *Prompt {
+	p := &Prompt{Title: "Main Phase", Question: "Choose an action"}
+	if req.GetIsBattleCmd() {
+		p.Title, p.Question = "Battle Phase", "Choose a battle action"
+	}
+
+	type action struct {
+		index  int
+		action duelpb.Answer_SelectIdle_Action
+		phase  uint32
+	}
+	var actions []action
+	add := func(label string, code uint32, a action) {
+		p.Options = append(p.Options, Option{Label: label, Code: code})
+		actions = append(actions, a)
+	}
+	places := func(verb string, list []*duelpb.Place, act duelpb.Answer_SelectIdle_Action) {
+		for i, place := range list {
+			add(verb+" "+n.at(place, 0), n.codeAt(place, 0), action{index: i, action: act})
+		}
+	}
+
+	for i, c := range req.GetActivableCards() {
+		add(fmt.Sprintf("Activate %s: %s", n.at(c.GetPlace(), 0), n.effect(c.GetEffect())), n.codeAt(c.GetPlace(), 0),
+			action{index: i, action: duelpb.Answer_SelectIdle_ACTION_ACTIVATE})
+	}
+	places("Normal Summon", req.GetSummonableCards(), duelpb.Answer_SelectIdle_ACTION_SUMMON)
+	places("Special Summon", req.GetSpsummonableCards(), duelpb.Answer_SelectIdle_ACTION_SPSUMMON)
+	places("Set", req.GetMsetableCards(), duelpb.Answer_SelectIdle_ACTION_MSET)
+	places("Set", req.GetSsetableCards(), duelpb.Answer_SelectIdle_ACTION_SSET)
+	places("Change the position of", req.GetRepositionableCards(), duelpb.Answer_SelectIdle_ACTION_REPOSITION)
+	for i, c := range req.GetCanAttackCards() {
+		label := "Attack with " + n.at(c.GetPlace(), 0)
+		if c.GetCanAttackDirectly() {
+			label += " (can attack directly)"
+		}
+		add(label, n.codeAt(c.GetPlace(), 0), action{index: i, action: duelpb.Answer_SelectIdle_ACTION_ATTACK})
+	}
+	for bit := uint32(1); bit != 0 && bit <= req.GetAvailablePhase(); bit <<= 1 {
+		if req.GetAvailablePhase()&bit != 0 {
+			add("Go to the "+n.cat.PhaseName(bit), 0, action{action: duelpb.Answer_SelectIdle_ACTION_PHASE, phase: bit})
+		}
+	}
+	if req.GetCanShuffle() {
+		add("Shuffle your hand", 0, action{action: duelpb.Answer_SelectIdle_ACTION_SHUFFLE})
+	}
+
+	p.done = func(sel []int) *duelpb.Answer {
+		a := actions[sel[0]]
+		idle := &duelpb.Answer_SelectIdle{}
+		switch a.action {
+		case duelpb.Answer_SelectIdle_ACTION_PHASE:
+			idle.T = &duelpb.Answer_SelectIdle_Phase{Phase: a.phase}
+		case duelpb.Answer_SelectIdle_ACTION_SHUFFLE:
+			idle.T = &duelpb.Answer_SelectIdle_Shuffle{Shuffle: true}
+		default:
+			idle.T = &duelpb.Answer_SelectIdle_CardAction_{CardAction: &duelpb.Answer_SelectIdle_CardAction{
+				Action: a.action,
+				Index:  uint32(a.index),
+			}}
+		}
+		return &duelpb.Answer{T: &duelpb.Answer_SelectIdle_{SelectIdle: idle}}
+	}
+	return p
+}
+
+func chainPrompt(req *duelpb.Msg_Request_SelectToChain, n namer) *Prompt {
+	noOp := &duelpb.Answer{T: &duelpb.Answer_SelectToChain_{SelectToChain: &duelpb.Answer_SelectToChain{
+		T: &duelpb.Answer_SelectToChain_NoOp{NoOp: true},
+	}}}
+	p := &Prompt{Title: "Chain", Question: "Chain an effect?"}
+	switch {
+	case req.GetForced():
+		p.Question = "Choose the effect to activate"
+	case req.GetTriggering():
+		p.Question = "Activate a trigger effect?"
+	}
+	for _, c := range req.GetActivableCards() {
+		p.Options = append(p.Options, Option{
+			Label: fmt.Sprintf("%s: %s", n.at(c.GetPlace(), 0), n.effect(c.GetEffect())),
+			Code:  n.codeAt(c.GetPlace(), c.GetEffect().GetCode()),
+		})
+	}
+	count := len(p.Options)
+	if !req.GetForced() {
+		p.Options = append(p.Options, Option{Label: "Don't activate"})
+		p.escape = noOp
+	}
+	p.done = func(sel []int) *duelpb.Answer {
+		if sel[0] >= count {
+			return noOp
+		}
+		return &duelpb.Answer{T: &duelpb.Answer_SelectToChain_{SelectToChain: &duelpb.Answer_SelectToChain{
+			T: &duelpb.Answer_SelectToChain_Index{Index: uint32(sel[0])},
+		}}}
+	}
+	return p
+}
+
+func cardPrompt(req *duelpb.Msg_Request_SelectCard, n namer) (*Prompt, error) {
+	p := &Prompt{Title: "Select cards", Mode: PickMany, done: cardIndexes}
+	cancel := func(ok bool) {
+		if ok {
+			p.escape = cardCancel
+		}
+	}
+
+	switch t := req.GetT().(type) {
+	case *duelpb.Msg_Request_SelectCard_Limbo_:
+		r := t.Limbo
+		for _, code := range r.GetCardCodes() {
+			p.Options = append(p.Options, Option{Label: n.card(code), Code: code})
+		}
+		p.Min, p.Max = int(r.GetMin()), int(r.GetMax())
+		cancel(r.GetCanCancel())
+
+	case *duelpb.Msg_Request_SelectCard_UniqueRange_:
+		r := t.UniqueRange
+		for _, c := range r.GetCards() {
+			p.Options = append(p.Options, Option{Label: n.at(c.GetPlace(), c.GetCode()), Code: n.codeAt(c.GetPlace(), c.GetCode())})
+		}
+		p.Min, p.Max = int(r.GetMin()), int(r.GetMax())
+		cancel(r.GetCanCancel())
+
+	case *duelpb.Msg_Request_SelectCard_Recursive_:
+		// One card per request: selectable cards first, then the ones that
+		// can be unmarked, indexed as a single list.
+		r := t.Recursive
+		p.Title = "Select cards one at a time"
+		p.Mode = PickOne
+		p.Question = fmt.Sprintf("Select %d to %d cards", r.GetMin(), r.GetMax())
+		for _, c := range r.GetSelectableCards() {
+			p.Options = append(p.Options, Option{Label: n.at(c.GetPlace(), c.GetCode()), Code: n.codeAt(c.GetPlace(), c.GetCode())})
+		}
+		for _, c := range r.GetDeselectableCards() {
+			p.Options = append(p.Options, Option{Label: "✓ " + n.at(c.GetPlace(), c.GetCode()) + " (unmark)", Code: n.codeAt(c.GetPlace(), c.GetCode())})
+		}
+		count := len(p.Options)
+		if r.GetCanFinish() {
+			label := "Cancel the selection"
+			if r.GetAccept() {
+				label = "Finish the selection"
+			}
+			p.Options = append(p.Options, Option{Label: label})
+			p.escape = cardFinish
+		}
+		p.done = func(sel []int) *duelpb.Answer {
+			if sel[0] >= count {
+				return cardFinish
+			}
+			return cardIndexes(sel)
+		}
+		return p, nil
+
+	case *duelpb.Msg_Request_SelectCard_UniqueSum_:
+		r := t.UniqueSum
+		params := make([][2]uint32, 0, len(r.GetCards()))
+		for _, c := range r.GetCards() {
+			label := n.at(c.GetPlace(), c.GetCode())
+			if c.GetParam_2() != 0 {
+				label += fmt.Sprintf(" [%d or %d]", c.GetParam_1(), c.GetParam_2())
+			} else {
+				label += fmt.Sprintf(" [%d]", c.GetParam_1())
+			}
+			p.Options = append(p.Options, Option{Label: label, Code: n.codeAt(c.GetPlace(), c.GetCode())})
+			params = append(params, [2]uint32{c.GetParam_1(), c.GetParam_2()})
+		}
+		p.Min, p.Max = 1, len(p.Options)
+		lo, hi := r.GetSumMin(), r.GetSumMax()
+		if r.GetSumExactly() {
+			p.Question = fmt.Sprintf("Select cards adding up to exactly %d", lo)
+			if hi > lo {
+				p.Question = fmt.Sprintf("Select cards adding up to %d to %d", lo, hi)
+			}
+		} else {
+			p.Question = fmt.Sprintf("Select cards adding up to at least %d", lo)
+		}
+		p.check = func(sel []int) error {
+			for _, s := range sums(sel, params) {
+				if s >= lo && (!r.GetSumExactly() || s <= max(hi, lo)) {
+					return nil
+				}
+			}
+			return fmt.Errorf("those cards do not add up")
+		}
+		cancel(r.GetCanCancel())
+
+	case *duelpb.Msg_Request_SelectCard_UniqueTributes_:
+		r := t.UniqueTributes
+		var counts []uint32
+		for _, c := range r.GetCards() {
+			label := n.at(c.GetPlace(), c.GetCode())
+			count := max(c.GetCountAs(), 1)
+			if count > 1 {
+				label += fmt.Sprintf(" (counts as %d)", count)
+			}
+			p.Options = append(p.Options, Option{Label: label, Code: n.codeAt(c.GetPlace(), c.GetCode())})
+			counts = append(counts, count)
+		}
+		p.Title = "Tribute"
+		p.Question = fmt.Sprintf("Select %d to %d Tributes", r.GetTributeMin(), r.GetTributeMax())
+		p.Min, p.Max = 1, len(p.Options)
+		p.check = func(sel []int) error {
+			total := uint32(0)
+			for _, i := range sel {
+				total += counts[i]
+			}
+			if total < r.GetTributeMin() || total > r.GetTributeMax() {
+				return fmt.Errorf("those cards make %d Tributes", total)
+			}
+			return nil
+		}
+		cancel(r.GetCanCancel())
+
+	default:
+		return nil, fmt.Errorf("unsupported card selection %T", req.GetT())
+	}
+
+	if p.Question == "" {
+		p.Question = fmt.Sprintf("Select %d to %d cards", p.Min, p.Max)
+		if p.Min == p.Max {
+			p.Question = fmt.Sprintf("Select %d card(s)", p.Min)
+		}
+	}
+	return p, nil
+}
+
+// sums returns every total the selected cards can make, each counting as
+// either of its two parameters (the second only when non-zero).
+func sums(sel []int, params [][2]uint32) []uint32 {
+	totals := []uint32{0}
+	for _, i := range sel {
+		var next []uint32
+		for _, t := range totals {
+			next = append(next, t+params[i][0])
+			if params[i][1] != 0 {
+				next = append(next, t+params[i][1])
+			}
+		}
+		totals = next
+	}
+	return totals
+}
+
+func zonePrompt(req *duelpb.Msg_Request_SelectZone, n namer) *Prompt {
+	p := &Prompt{Title: "Select zones", Mode: PickMany, Min: int(req.GetCount()), Max: int(req.GetCount())}
+	p.Question = fmt.Sprintf("Choose %d zone(s)", req.GetCount())
+	if req.GetBlocking() {
+		p.Question = fmt.Sprintf("Choose %d zone(s) to block", req.GetCount())
+	}
+	for _, place := range req.GetPlaces() {
+		label := fmt.Sprintf("%s · %s", n.cat.ZoneName(place, n.board.SeparatePZones), n.cat.PlayerName(int(place.GetCon())))
+		if c := n.board.At(place); c != nil {
+			label += " (" + n.card(c.Code) + ")"
+		}
+		p.Options = append(p.Options, Option{Label: label, Code: n.codeAt(place, 0)})
+	}
+	p.done = func(sel []int) *duelpb.Answer {
+		zone := &duelpb.Answer_SelectZone{}
+		for _, i := range sel {
+			zone.Places = append(zone.Places, proto.Clone(req.GetPlaces()[i]).(*duelpb.Place))
+		}
+		return &duelpb.Answer{T: &duelpb.Answer_SelectZone_{SelectZone: zone}}
+	}
+	return p
+}
+
+func positionPrompt(req *duelpb.Msg_Request_SelectPosition, n namer) *Prompt {
+	p := &Prompt{Title: "Position", Question: "Choose a position for " + n.card(req.GetCode())}
+	var flags []uint32
+	for _, pos := range positionNames {
+		if req.GetPosition()&pos.flag != 0 {
+			p.Options = append(p.Options, Option{Label: pos.name, Code: req.GetCode()})
+			flags = append(flags, pos.flag)
+		}
+	}
+	p.done = func(sel []int) *duelpb.Answer {
+		return &duelpb.Answer{T: &duelpb.Answer_SelectPosition{SelectPosition: flags[sel[0]]}}
+	}
+	return p
+}
+
+func yesNoPrompt(req *duelpb.Msg_Request_SelectYesNo, n namer) *Prompt {
+	no := &duelpb.Answer{T: &duelpb.Answer_SelectYesNo{SelectYesNo: false}}
+	p := &Prompt{Title: "Yes or no", Question: "Confirm?", escape: no}
+	code := n.codeAt(req.GetPlace(), req.GetCode())
+	if req.GetEffect() != nil {
+		p.Question = n.effect(req.GetEffect())
+	}
+	if code != 0 {
+		p.Question = n.card(code) + ": " + p.Question
+	}
+	p.Options = []Option{{Label: "Yes", Code: code}, {Label: "No", Code: code}}
+	p.done = func(sel []int) *duelpb.Answer {
+		return &duelpb.Answer{T: &duelpb.Answer_SelectYesNo{SelectYesNo: sel[0] == 0}}
+	}
+	return p
+}
+
+func effectPrompt(req *duelpb.Msg_Request_SelectEffect, n namer) *Prompt {
+	p := &Prompt{Title: "Select an effect", Question: "Choose an effect"}
+	for _, e := range req.GetEffects() {
+		p.Options = append(p.Options, Option{Label: n.effect(e), Code: e.GetCode()})
+	}
+	p.done = func(sel []int) *duelpb.Answer {
+		return &duelpb.Answer{T: &duelpb.Answer_SelectEffect{SelectEffect: uint32(sel[0])}}
+	}
+	return p
+}
+
+func numberPrompt(req *duelpb.Msg_Request_SelectNumber) *Prompt {
+	p := &Prompt{Title: "Select a number", Question: "Declare a number"}
+	for _, v := range req.GetNumbers() {
+		p.Options = append(p.Options, Option{Label: fmt.Sprint(v)})
+	}
+	p.done = func(sel []int) *duelpb.Answer {
+		return &duelpb.Answer{T: &duelpb.Answer_SelectNumber{SelectNumber: uint32(sel[0])}}
+	}
+	return p
+}
+
+func attributePrompt(req *duelpb.Msg_Request_SelectAttribute) *Prompt {
+	count := int(req.GetCount())
+	p := &Prompt{Title: "Declare an Attribute", Mode: PickMany, Min: count, Max: count}
+	p.Question = fmt.Sprintf("Declare %d Attribute(s)", count)
+	var flags []uint32
+	for _, a := range carddb.AttributeNames {
+		if req.GetAttribute()&a.Flag != 0 {
+			p.Options = append(p.Options, Option{Label: a.Name})
+			flags = append(flags, a.Flag)
+		}
+	}
+	p.done = func(sel []int) *duelpb.Answer {
+		var attr uint32
+		for _, i := range sel {
+			attr |= flags[i]
+		}
+		return &duelpb.Answer{T: &duelpb.Answer_SelectAttribute{SelectAttribute: attr}}
+	}
+	return p
+}
+
+func racePrompt(req *duelpb.Msg_Request_SelectRace) *Prompt {
+	count := int(req.GetCount())
+	p := &Prompt{Title: "Declare a Type", Mode: PickMany, Min: count, Max: count}
+	p.Question = fmt.Sprintf("Declare %d monster Type(s)", count)
+	var flags []uint64
+	for _, r := range carddb.RaceNames {
+		if req.GetRace()&r.Flag != 0 {
+			p.Options = append(p.Options, Option{Label: r.Name})
+			flags = append(flags, r.Flag)
+		}
+	}
+	p.done = func(sel []int) *duelpb.Answer {
+		var race uint64
+		for _, i := range sel {
+			race |= flags[i]
+		}
+		return &duelpb.Answer{T: &duelpb.Answer_SelectRace{SelectRace: race}}
+	}
+	return p
+}
+
+func counterPrompt(req *duelpb.Msg_Request_SelectCounter, n namer) *Prompt {
+	need := int(req.GetCounter().GetCount())
+	p := &Prompt{Title: "Remove counters", Mode: PickAmounts, Max: need}
+	p.Question = fmt.Sprintf("Remove %d counter(s)", need)
+	for _, c := range req.GetCards() {
+		p.Options = append(p.Options, Option{Label: n.at(c.GetPlace(), 0), Code: n.codeAt(c.GetPlace(), 0), Limit: int(c.GetAmount())})
+	}
+	p.done = func(amounts []int) *duelpb.Answer {
+		counter := &duelpb.Answer_SelectCounter{}
+		for i, a := range amounts {
+			if a > 0 {
+				counter.Values = append(counter.Values, &duelpb.Answer_SelectCounter_XPair{Index: uint32(i), Amount: uint32(a)})
+			}
+		}
+		return &duelpb.Answer{T: &duelpb.Answer_SelectCounter_{SelectCounter: counter}}
+	}
+	return p
+}
+
+func sortPrompt(req *duelpb.Msg_Request_Sort, n namer) *Prompt {
+	p := &Prompt{Title: "Order cards", Mode: PickOrder, Question: "Put the cards in order, first card first"}
+	for _, place := range req.GetPlaces() {
+		p.Options = append(p.Options, Option{Label: n.at(place, 0), Code: n.codeAt(place, 0)})
+	}
+	p.escape = &duelpb.Answer{T: &duelpb.Answer_Sort_{Sort: &duelpb.Answer_Sort{T: &duelpb.Answer_Sort_Skip{Skip: true}}}}
+	// As in ygopro's sort response, value i is the new position of card i.
+	p.done = func(order []int) *duelpb.Answer {
+		pos := make([]int, len(order))
+		for at, i := range order {
+			pos[i] = at
+		}
+		return &duelpb.Answer{T: &duelpb.Answer_Sort_{Sort: &duelpb.Answer_Sort{T: &duelpb.Answer_Sort_Indexes{Indexes: indexes(pos)}}}}
+	}
+	return p
+}
+
+func rpsPrompt() *Prompt {
+	// Values as in ygopro: 1 scissors, 2 rock, 3 paper.
+	p := &Prompt{Title: "Rock-paper-scissors", Question: "Choose your hand"}
+	p.Options = []Option{{Label: "Rock"}, {Label: "Paper"}, {Label: "Scissors"}}
+	values := []int32{2, 3, 1}
+	p.done = func(sel []int) *duelpb.Answer {
+		return &duelpb.Answer{T: &duelpb.Answer_SelectRockPaperScissors{SelectRockPaperScissors: values[sel[0]]}}
+	}
+	return p
+}
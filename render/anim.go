@@ -0,0 +1,231 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"io"
+	"time"
+
+	"github.com/spb8026/ygo-visualizer/carddb"
+	"github.com/spb8026/ygo-visualizer/state"
+	duelpb "github.com/spb8026/ygo-visualizer/ygopenpb"
+)
+
+// DefaultDelays is how long each kind of event stays on screen when
+// AnimationOptions.Delays does not say.
+var DefaultDelays = map[string]time.Duration{
+	"turn":   2 * time.Second,
+	"phase":  time.Second,
+	"card":   600 * time.Millisecond,
+	"chain":  time.Second,
+	"lp":     time.Second,
+	"result": time.Second,
+	"finish": 3 * time.Second,
+}
+
+// DefaultDelay is used for kinds of event missing from DefaultDelays.
+const DefaultDelay = 400 * time.Millisecond
+
+// EventKind names the kind of event m carries, for frame timing: "board",
+// "card", "chain", "turn", "finish", "lp", "meta", "phase", "pile",
+// "result" or "zone_block". It is "" for messages that are not events.
+func EventKind(m *duelpb.Msg) string {
+	switch m.GetEvent().GetT().(type) {
+	case *duelpb.Msg_Event_Board_:
+		return "board"
+	case *duelpb.Msg_Event_Card_:
+		return "card"
+	case *duelpb.Msg_Event_ChainStack_:
+		return "chain"
+	case *duelpb.Msg_Event_NextTurn:
+		return "turn"
+	case *duelpb.Msg_Event_Finish_:
+		return "finish"
+	case *duelpb.Msg_Event_Lp:
+		return "lp"
+	case *duelpb.Msg_Event_Meta_:
+		return "meta"
+	case *duelpb.Msg_Event_NextPhase:
+		return "phase"
+	case *duelpb.Msg_Event_Pile_:
+		return "pile"
+	case *duelpb.Msg_Event_Result_:
+		return "result"
+	case *duelpb.Msg_Event_ZoneBlock_:
+		return "zone_block"
+	}
+	return ""
+}
+
+// AnimationOptions controls an Animation.
+type AnimationOptions struct {
+	Frame FrameOptions
+	// FromTurn and ToTurn keep the events of a range of turns; 0 leaves
+	// that end open.
+	FromTurn, ToTurn int
+	// FromEvent and ToEvent keep a range of events, numbered from 1 in the
+	// order they are added; 0 leaves that end open.
+	FromEvent, ToEvent int
+	// Delays overrides DefaultDelays by EventKind.
+	Delays map[string]time.Duration
+}
+
+// Animation assembles an animated GIF of a duel segment, one frame per
+// event. Frames only store the pixels that changed since the previous
+// one, so long segments stay small.
+type Animation struct {
+	opts   AnimationOptions
+	events int
+	pal    color.Palette
+	index  map[color.RGBA]uint8
+	prev   *image.RGBA
+	g      gif.GIF
+}
+
+func NewAnimation(opts AnimationOptions) *Animation {
+	opts.Frame.ReserveChain = true
+	return &Animation{opts: opts, pal: gifPalette(), index: make(map[color.RGBA]uint8)}
+}
+
+// Add records b, the board after m was applied to it. Messages that are
+// not events, and events outside the range, add no frame.
+func (a *Animation) Add(b *state.Board, m *duelpb.Msg) {
+	kind := EventKind(m)
+	if kind == "" {
+		return
+	}
+	a.events++
+	o := a.opts
+	if (o.FromEvent > 0 && a.events < o.FromEvent) || (o.ToEvent > 0 && a.events > o.ToEvent) ||
+		(o.FromTurn > 0 && b.Turn < o.FromTurn) || (o.ToTurn > 0 && b.Turn > o.ToTurn) {
+		return
+	}
+	a.add(Frame(b, o.Frame), a.delay(kind))
+}
+
+// Frames returns the number of frames so far.
+func (a *Animation) Frames() int {
+	return len(a.g.Image)
+}
+
+// WriteGIF encodes the animation, which loops forever.
+func (a *Animation) WriteGIF(w io.Writer) error {
+	return gif.EncodeAll(w, &a.g)
+}
+
+func (a *Animation) delay(kind string) time.Duration {
+	if d, ok := a.opts.Delays[kind]; ok {
+		return d
+	}
+	if d, ok := DefaultDelays[kind]; ok {
+		return d
+	}
+	return DefaultDelay
+}
+
+func (a *Animation) add(img *image.RGBA, d time.Duration) {
+	cs := int(d / (10 * time.Millisecond))
+	if a.prev == nil {
+		// The first frame sets the size of the whole animation.
+		a.prev = img
+		a.g.Config = image.Config{ColorModel: a.pal, Width: img.Bounds().Dx(), Height: img.Bounds().Dy()}
+		a.push(img, img.Bounds(), cs)
+		return
+	}
+	if img.Bounds() != a.prev.Bounds() {
+		// A field shape change can shorten the board; keep the first
+		// frame's size.
+		fit := image.NewRGBA(a.prev.Bounds())
+		draw.Draw(fit, fit.Bounds(), image.NewUniform(colorBackground), image.Point{}, draw.Src)
+		draw.Draw(fit, img.Bounds(), img, image.Point{}, draw.Src)
+		img = fit
+	}
+	changed := diff(a.prev, img)
+	a.prev = img
+	if changed.Empty() {
+		a.g.Delay[len(a.g.Delay)-1] += cs
+		return
+	}
+	a.push(img, changed, cs)
+}
+
+// push appends the part r of img as a frame drawn over the previous one.
+func (a *Animation) push(img *image.RGBA, r image.Rectangle, cs int) {
+	p := image.NewPaletted(r, a.pal)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			c := img.RGBAAt(x, y)
+			i, ok := a.index[c]
+			if !ok {
+				i = uint8(a.pal.Index(c))
+				a.index[c] = i
+			}
+			p.Pix[p.PixOffset(x, y)] = i
+		}
+	}
+	a.g.Image = append(a.g.Image, p)
+	a.g.Delay = append(a.g.Delay, cs)
+	a.g.Disposal = append(a.g.Disposal, gif.DisposalNone)
+}
+
+// diff returns the smallest rectangle holding every pixel that differs
+// between two images of the same bounds.
+func diff(a, b *image.RGBA) image.Rectangle {
+	r := image.Rectangle{}
+	bounds := a.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		ra := a.Pix[a.PixOffset(bounds.Min.X, y):a.PixOffset(bounds.Max.X, y)]
+		rb := b.Pix[b.PixOffset(bounds.Min.X, y):b.PixOffset(bounds.Max.X, y)]
+		if string(ra) == string(rb) {
+			continue
+		}
+		first, last := -1, 0
+		for x := 0; x < len(ra); x += 4 {
+			if ra[x] != rb[x] || ra[x+1] != rb[x+1] || ra[x+2] != rb[x+2] || ra[x+3] != rb[x+3] {
+				if first < 0 {
+					first = x / 4
+				}
+				last = x / 4
+			}
+		}
+		r = r.Union(image.Rect(bounds.Min.X+first, y, bounds.Min.X+last+1, y+1))
+	}
+	return r
+}
+
+// gifPalette holds every color the renderer draws with, then web-safe
+// colors for card artwork.
+func gifPalette() color.Palette {
+	var pal color.Palette
+	seen := make(map[color.RGBA]bool)
+	addColor := func(c color.RGBA) {
+		if !seen[c] && len(pal) < 256 {
+			seen[c] = true
+			pal = append(pal, c)
+		}
+	}
+	for _, c := range []color.RGBA{
+		colorBackground, colorPanel, colorOutline, colorLabel, colorSubtle, colorEdge, colorPileEdge,
+		colorBackRing, colorMaterial, colorPileBadge, colorCounter, colorTarget, colorWhite,
+		inkDark, inkLight, frameBack.fill, frameUnknown.fill, mix(frameBack.ink, frameBack.fill),
+		lpColor(fullLP), lpColor(fullLP / 2), lpColor(0),
+		chainColor(&state.Link{}), chainColor(&state.Link{Outcome: state.OutcomeResolved}),
+	} {
+		addColor(c)
+	}
+	for _, t := range []uint32{
+		carddb.TYPE_MONSTER | carddb.TYPE_EFFECT, carddb.TYPE_MONSTER | carddb.TYPE_NORMAL,
+		carddb.TYPE_MONSTER | carddb.TYPE_FUSION, carddb.TYPE_MONSTER | carddb.TYPE_RITUAL,
+		carddb.TYPE_MONSTER | carddb.TYPE_SYNCHRO, carddb.TYPE_MONSTER | carddb.TYPE_XYZ,
+		carddb.TYPE_MONSTER | carddb.TYPE_LINK, carddb.TYPE_SPELL, carddb.TYPE_TRAP,
+	} {
+		addColor(frameOf(Face{Type: t}).fill)
+	}
+	for _, c := range palette.WebSafe {
+		addColor(color.RGBAModel.Convert(c).(color.RGBA))
+	}
+	return pal
+}
@@ -0,0 +1,89 @@
+package render
+
+import (
+	"fmt"
+
+	"github.com/spb8026/ygo-visualizer/carddb"
+	"github.com/spb8026/ygo-visualizer/state"
+)
+
+// Face is what a renderer shows of one card.
+type Face struct {
+	Code     uint32
+	Name     string
+	FaceDown bool
+	Defense  bool
+	Monster  bool
+	Link     bool
+	// Type holds the card's TYPE_* bits, or 0 if it is not in the database.
+	Type     uint32
+	Atk, Def int32
+	// Rating is the Link rating of Link monsters, else the Level or Rank.
+	Rating int32
+}
+
+// Stats formats ATK/DEF, or ATK/LINK-n, for known monsters.
+func (f Face) Stats() string {
+	if !f.Monster || f.Code == 0 {
+		return ""
+	}
+	if f.Link {
+		return fmt.Sprintf("%d/LINK-%d", f.Atk, f.Rating)
+	}
+	return fmt.Sprintf("%d/%d", f.Atk, f.Def)
+}
+
+// FaceOf describes c. monster tells whether it sits in a monster zone;
+// db may be nil, in which case cards are named by code.
+func FaceOf(c *state.Card, monster bool, db carddb.Source) Face {
+	f := Face{
+		Code:     c.Code,
+		FaceDown: c.Position&state.POS_FACEDOWN != 0,
+		Defense:  c.Position&(state.POS_FACEUP_DEFENSE|state.POS_FACEDOWN_DEFENSE) != 0,
+		Monster:  monster,
+		Atk:      c.Atk,
+		Def:      c.Def,
+		Rating:   c.Level,
+	}
+	f.Name = CardName(c.Code, db)
+	if c.Code == 0 || db == nil {
+		return f
+	}
+	data, err := db.GetCard(c.Code)
+	if err != nil || data == nil {
+		return f
+	}
+	f.Monster = monster || data.IsMonster()
+	f.Type = data.Type
+	f.Link = data.Type&carddb.TYPE_LINK != 0
+	if f.Atk == 0 && f.Def == 0 {
+		f.Atk, f.Def = data.Attack, data.Defense
+	}
+	if f.Rating == 0 || f.Link {
+		f.Rating = int32(data.Level)
+	}
+	return f
+}
+
+// HeldFace describes a card held in a pile or hand, which shows its face
+// whenever the viewer knows it, upright.
+func HeldFace(c *state.Card, db carddb.Source) Face {
+	f := FaceOf(c, false, db)
+	f.FaceDown = c.Code == 0
+	f.Defense = false
+	return f
+}
+
+// CardName returns the card's name from db, or "#code", or "?" for an
+// unknown card.
+func CardName(code uint32, db carddb.Source) string {
+	if code == 0 {
+		return "?"
+	}
+	if db != nil {
+		if text, err := db.GetText(code); err == nil && text != nil && text.Name != "" {
+			return text.Name
+		}
+	}
+	return fmt.Sprintf("#%d", code)
+}
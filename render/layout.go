@@ -0,0 +1,140 @@
+// Package render draws a state.Board for people to look at.
+package render
+
+import (
+	"fmt"
+
+	"github.com/spb8026/ygo-visualizer/state"
+)
+
+// The field is drawn as a grid of Rows x Cols cells seen from player 0's
+// side: the opponent's spell and monster rows on top, the shared Extra
+// Monster Zone row in the middle, player 0's monster and spell rows below.
+// The opponent's rows are rotated, so their first zone is on the right.
+const (
+	Rows = 5
+	Cols = 7
+
+	RowEMZ = 2
+)
+
+// Slot is one cell of the grid: a zone, or a pile shown by its top card.
+type Slot struct {
+	Con int
+	Loc uint32
+	// Seq is the zone sequence within LOC_MZONE or LOC_SZONE.
+	Seq      int
+	Row, Col int
+}
+
+// Pile reports whether the slot shows a pile rather than a zone.
+func (s Slot) Pile() bool {
+	return s.Loc != state.LOC_MZONE && s.Loc != state.LOC_SZONE
+}
+
+// Card returns the card in the zone, or the top card of the pile, or nil.
+func (s Slot) Card(b *state.Board) *state.Card {
+	p := b.Players[s.Con]
+	switch s.Loc {
+	case state.LOC_MZONE:
+		return p.Monsters[s.Seq]
+	case state.LOC_SZONE:
+		return p.Spells[s.Seq]
+	}
+	if pile := *p.Pile(s.Loc); len(pile) > 0 {
+		return pile[len(pile)-1]
+	}
+	return nil
+}
+
+// Count returns the number of cards in a pile slot, or of Xyz materials
+// under a monster zone.
+func (s Slot) Count(b *state.Board) int {
+	p := b.Players[s.Con]
+	switch s.Loc {
+	case state.LOC_MZONE:
+		return len(p.Overlays[s.Seq])
+	case state.LOC_SZONE:
+		return 0
+	}
+	return len(*p.Pile(s.Loc))
+}
+
+// Label is the short name drawn in an empty slot.
+func (s Slot) Label(b *state.Board) string {
+	switch s.Loc {
+	case state.LOC_MZONE:
+		if s.Seq >= state.SeqEMZLeft {
+			return "EMZ"
+		}
+		return fmt.Sprintf("M%d", s.Seq+1)
+	case state.LOC_SZONE:
+		switch {
+		case s.Seq == state.SeqFieldZone:
+			return "Field"
+		case s.Seq >= state.SeqPZoneLeft:
+			return "PZ"
+		case b.HasPZones() && !b.SeparatePZones && (s.Seq == 0 || s.Seq == 4):
+			return fmt.Sprintf("S%d/PZ", s.Seq+1)
+		}
+		return fmt.Sprintf("S%d", s.Seq+1)
+	case state.LOC_GRAVE:
+		return "GY"
+	case state.LOC_EXTRA:
+		return "Extra"
+	case state.LOC_DECK:
+		return "Deck"
+	}
+	return state.LocationName(s.Loc)
+}
+
+// Slots lays out every zone and the deck, Extra Deck and GY for b's field
+// shape. Both players' Extra Monster Zones share the cells of RowEMZ; at
+// most one of each pair is occupied.
+func Slots(b *state.Board) []Slot {
+	var out []Slot
+	for con := 0; con < 2; con++ {
+		monsters, spells := 3, 4
+		place := func(loc uint32, seq, row, col int) {
+			if con == 1 {
+				row, col = Rows-1-row, Cols-1-col
+			}
+			out = append(out, Slot{Con: con, Loc: loc, Seq: seq, Row: row, Col: col})
+		}
+
+		place(state.LOC_SZONE, state.SeqFieldZone, monsters, 0)
+		for seq := 0; seq < 5; seq++ {
+			place(state.LOC_MZONE, seq, monsters, seq+1)
+			place(state.LOC_SZONE, seq, spells, seq+1)
+		}
+		place(state.LOC_GRAVE, 0, monsters, Cols-1)
+
+		if b.SeparatePZones {
+			place(state.LOC_SZONE, state.SeqPZoneLeft, spells, 0)
+			place(state.LOC_SZONE, state.SeqPZoneRight, spells, Cols-1)
+		} else {
+			place(state.LOC_EXTRA, 0, spells, 0)
+			place(state.LOC_DECK, 0, spells, Cols-1)
+		}
+
+		if b.HasEMZ() {
+			place(state.LOC_MZONE, state.SeqEMZLeft, RowEMZ, 2)
+			place(state.LOC_MZONE, state.SeqEMZRight, RowEMZ, 4)
+		}
+	}
+	return out
+}
+
+// Grid places slots in a Rows x Cols array. For shared cells the occupied
+// slot wins, then player 0's.
+func Grid(b *state.Board) [Rows][Cols]*Slot {
+	var grid [Rows][Cols]*Slot
+	for _, s := range Slots(b) {
+		s := s
+		cur := grid[s.Row][s.Col]
+		if cur == nil || (cur.Card(b) == nil && s.Card(b) != nil) {
+			grid[s.Row][s.Col] = &s
+		}
+	}
+	return grid
+}
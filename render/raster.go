@@ -0,0 +1,409 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg" // pics folders hold .jpg artwork
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spb8026/ygo-visualizer/carddb"
+	"github.com/spb8026/ygo-visualizer/narrate"
+	"github.com/spb8026/ygo-visualizer/state"
+)
+
+// FrameOptions controls Frame.
+type FrameOptions struct {
+	// DB names cards and colors their frames; nil shows codes.
+	DB carddb.Source
+	// Bottom is the player drawn at the bottom of the field.
+	Bottom int
+	// OmitHands leaves out both hands.
+	OmitHands bool
+	// Art draws face-up cards with their pictures, if set.
+	Art *Artwork
+	// ReserveChain keeps room for the chain panel even when no chain is
+	// open, so that consecutive frames have the same size.
+	ReserveChain bool
+}
+
+// Frame draws b as an image laid out like SVG, one pixel per SVG unit.
+// Text is drawn with a built-in ASCII font.
+func Frame(b *state.Board, opts FrameOptions) *image.RGBA {
+	s := newScene(b, opts.DB, opts.Bottom, !opts.OmitHands, opts.ReserveChain)
+	r := &raster{img: image.NewRGBA(image.Rect(0, 0, s.width, s.height)), art: opts.Art}
+	fillRect(r.img, rect{0, 0, s.width, s.height}, colorBackground)
+
+	label(r.img, s.header.x, s.header.y+6, 2, inkLight, anchorStart,
+		fmt.Sprintf("Turn %d · %s's turn · %s", b.Turn, narrate.PlayerName(b.TurnPlayer), narrate.PhaseName(b.Phase)))
+	for con := 0; con < 2; con++ {
+		r.bar(s, con)
+		if s.hands[con].w > 0 {
+			hand := b.Players[con].Hand
+			for i, c := range hand {
+				r.card(s.handCard(con, i, len(hand)), HeldFace(c, s.db), nil)
+			}
+		}
+	}
+	for row := 0; row < Rows; row++ {
+		for col := 0; col < Cols; col++ {
+			if slot := s.grid[row][col]; slot != nil && s.rowH[row] > 0 {
+				r.slot(s, slot)
+			}
+		}
+	}
+	r.chain(s)
+	return r.img
+}
+
+var (
+	colorBackground = color.RGBA{0x20, 0x25, 0x2b, 0xff}
+	colorPanel      = color.RGBA{0x2d, 0x34, 0x3c, 0xff}
+	colorOutline    = color.RGBA{0x4a, 0x52, 0x5c, 0xff}
+	colorLabel      = color.RGBA{0x80, 0x88, 0x90, 0xff}
+	colorSubtle     = color.RGBA{0xb0, 0xb8, 0xc0, 0xff}
+	colorEdge       = color.RGBA{0x10, 0x12, 0x14, 0xff}
+	colorPileEdge   = color.RGBA{0x5a, 0x40, 0x20, 0xff}
+	colorBackRing   = color.RGBA{0xc8, 0x90, 0x50, 0xff}
+	colorMaterial   = color.RGBA{0x3a, 0x3a, 0x3a, 0xff}
+	colorPileBadge  = color.RGBA{0x50, 0x58, 0x60, 0xff}
+	colorCounter    = color.RGBA{0x2e, 0x7d, 0xd0, 0xff}
+	colorTarget     = color.RGBA{0xff, 0x50, 0x50, 0xff}
+	colorWhite      = color.RGBA{0xff, 0xff, 0xff, 0xff}
+)
+
+type anchor int
+
+const (
+	anchorStart anchor = iota
+	anchorMiddle
+	anchorEnd
+)
+
+type raster struct {
+	img *image.RGBA
+	art *Artwork
+}
+
+func fillRect(img *image.RGBA, rc rect, c color.RGBA) {
+	draw.Draw(img, image.Rect(rc.x, rc.y, rc.x+rc.w, rc.y+rc.h), image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+// strokeRect outlines rc with lines width pixels thick, dashed if dash > 0.
+func strokeRect(img *image.RGBA, rc rect, c color.RGBA, width, dash int) {
+	on := func(i int) bool { return dash == 0 || (i/dash)%2 == 0 }
+	for i := 0; i < rc.w; i++ {
+		if on(i) {
+			fillRect(img, rect{rc.x + i, rc.y, 1, width}, c)
+			fillRect(img, rect{rc.x + i, rc.y + rc.h - width, 1, width}, c)
+		}
+	}
+	for i := 0; i < rc.h; i++ {
+		if on(i) {
+			fillRect(img, rect{rc.x, rc.y + i, width, 1}, c)
+			fillRect(img, rect{rc.x + rc.w - width, rc.y + i, width, 1}, c)
+		}
+	}
+}
+
+// strokeEllipse draws an ellipse outline width pixels thick.
+func strokeEllipse(img *image.RGBA, cx, cy, rx, ry, width int, c color.RGBA) {
+	for y := -ry - width; y <= ry+width; y++ {
+		for x := -rx - width; x <= rx+width; x++ {
+			outer := float64(x*x)/float64((rx+width)*(rx+width)) + float64(y*y)/float64((ry+width)*(ry+width))
+			inner := float64(x*x)/float64(rx*rx) + float64(y*y)/float64(ry*ry)
+			if outer <= 1 && inner >= 1 {
+				img.SetRGBA(cx+x, cy+y, c)
+			}
+		}
+	}
+}
+
+func fillDisc(img *image.RGBA, cx, cy, radius int, c color.RGBA) {
+	for y := -radius; y <= radius; y++ {
+		for x := -radius; x <= radius; x++ {
+			if x*x+y*y <= radius*radius {
+				img.SetRGBA(cx+x, cy+y, c)
+			}
+		}
+	}
+}
+
+// label draws s with its top at y, aligned on x.
+func label(img *image.RGBA, x, y, scale int, ink color.RGBA, a anchor, s string) {
+	switch a {
+	case anchorMiddle:
+		x -= textPixels(s, scale) / 2
+	case anchorEnd:
+		x -= textPixels(s, scale)
+	}
+	drawText(img, x, y, s, ink, scale)
+}
+
+// badge draws a small labelled disc centered on x, y.
+func (r *raster) badge(x, y int, fill color.RGBA, text string) {
+	fillDisc(r.img, x, y, 11, colorWhite)
+	fillDisc(r.img, x, y, 10, fill)
+	label(r.img, x, y-3, 1, inkLight, anchorMiddle, text)
+}
+
+func (r *raster) bar(s *scene, con int) {
+	rc := s.bars[con]
+	p := s.b.Players[con]
+	fillRect(r.img, rc, colorPanel)
+	name := narrate.PlayerName(con)
+	if s.b.TurnPlayer == con {
+		name = "> " + name
+	}
+	label(r.img, rc.x+12, rc.y+8, 2, inkLight, anchorStart, name)
+	label(r.img, rc.x+12, rc.y+32, 1, colorSubtle, anchorStart,
+		fmt.Sprintf("Hand %d · Deck %d · Extra %d · GY %d · Banished %d",
+			len(p.Hand), len(p.Deck), len(p.Extra), len(p.Grave), len(p.Banished)))
+
+	bx, by := rc.x+rc.w-lpBarW-12, rc.y+24
+	label(r.img, bx-8, by-2, 2, inkLight, anchorEnd, fmt.Sprintf("LP %d", p.LP))
+	fillRect(r.img, rect{bx, by, lpBarW, 12}, colorOutline)
+	fillRect(r.img, rect{bx, by, lpWidth(p.LP, lpBarW), 12}, lpColor(p.LP))
+}
+
+func (r *raster) slot(s *scene, slot *Slot) {
+	rc := s.cell(slot.Row, slot.Col)
+	b := s.b
+	strokeRect(r.img, rc, colorOutline, 1, 4)
+	label(r.img, rc.x+6, rc.y+6, 1, colorLabel, anchorStart, slot.Label(b))
+
+	card := slot.Card(b)
+	if card == nil {
+		return
+	}
+	box := rc.centered(cardW, cardH)
+	if slot.Pile() {
+		n := slot.Count(b)
+		f := HeldFace(card, s.db)
+		if slot.Loc != state.LOC_GRAVE {
+			f.FaceDown = f.FaceDown || card.Position&state.POS_FACEDOWN != 0
+		}
+		for i := min(n-1, 3); i > 0; i-- {
+			fillRect(r.img, rect{box.x + 2*i, box.y + 2*i, box.w, box.h}, colorPileEdge)
+			strokeRect(r.img, rect{box.x + 2*i, box.y + 2*i, box.w, box.h}, colorBackground, 1, 0)
+		}
+		r.card(box, f, nil)
+		r.badge(box.x+box.w-4, box.y+box.h-4, colorPileBadge, fmt.Sprint(n))
+		return
+	}
+
+	f := FaceOf(card, slot.Loc == state.LOC_MZONE, s.db)
+	n := slot.Count(b)
+	if n > 0 {
+		// Materials peek out from under the Xyz monster.
+		for i := min(n, 3); i > 0; i-- {
+			fillRect(r.img, rect{box.x - 3*i, box.y - 3*i, box.w, box.h}, colorMaterial)
+			strokeRect(r.img, rect{box.x - 3*i, box.y - 3*i, box.w, box.h}, colorSubtle, 1, 0)
+		}
+	}
+	r.card(box, f, card.Counters)
+	if n > 0 {
+		r.badge(rc.x+rc.w-12, rc.y+12, colorMaterial, fmt.Sprintf("×%d", n))
+	}
+}
+
+// card draws one card in box, turned sideways in Defense Position.
+func (r *raster) card(box rect, f Face, counters []state.Counter) {
+	face := image.NewRGBA(image.Rect(0, 0, box.w, box.h))
+	r.face(face, f)
+	if f.Monster && f.Defense {
+		// Turn a quarter counterclockwise: the top edge ends up on the left.
+		to := box.centered(box.h, box.w)
+		for y := 0; y < box.h; y++ {
+			for x := 0; x < box.w; x++ {
+				r.img.SetRGBA(to.x+y, to.y+box.w-1-x, face.RGBAAt(x, y))
+			}
+		}
+	} else {
+		draw.Draw(r.img, image.Rect(box.x, box.y, box.x+box.w, box.y+box.h), face, image.Point{}, draw.Src)
+	}
+	for i, ctr := range counters {
+		x, y := counterAt(box, f, i)
+		r.badge(x, y, colorCounter, fmt.Sprint(ctr.Count))
+	}
+}
+
+// face draws the card itself into img, which is one card in size.
+func (r *raster) face(img *image.RGBA, f Face) {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	full := rect{0, 0, w, h}
+	fr := frameOf(f)
+
+	if pic := r.art.Get(f.Code, w, h); pic != nil && !f.FaceDown {
+		draw.Draw(img, img.Bounds(), pic, image.Point{}, draw.Src)
+		strokeRect(img, full, colorEdge, 1, 0)
+		if f.Monster {
+			// Current stats over the printed ones.
+			fillRect(img, rect{0, h - 30, w, 30}, colorEdge)
+			r.statLines(img, f, h-26, inkLight)
+		}
+		return
+	}
+
+	fillRect(img, full, fr.fill)
+	strokeRect(img, full, colorEdge, 1, 0)
+	if f.FaceDown {
+		strokeEllipse(img, w/2, h/2, w/3, h/4, 2, colorBackRing)
+		if f.Code == 0 {
+			return
+		}
+	}
+	// A face-down card its owner knows is still labelled, dimmed.
+	ink := fr.ink
+	if f.FaceDown {
+		ink = mix(fr.ink, fr.fill)
+	}
+	for i, line := range wrapCols(f.Name, 14, 3) {
+		label(img, 5, 6+12*i, 1, ink, anchorStart, line)
+	}
+	if f.Monster && f.Type != 0 {
+		rating := fmt.Sprintf("★%d", f.Rating)
+		switch {
+		case f.Link:
+			rating = fmt.Sprintf("LINK-%d", f.Rating)
+		case f.Type&carddb.TYPE_XYZ != 0:
+			rating = fmt.Sprintf("☆%d", f.Rating)
+		}
+		label(img, w-5, 48, 1, ink, anchorEnd, rating)
+	}
+	if f.Monster && f.Code != 0 {
+		r.statLines(img, f, h-26, ink)
+	}
+}
+
+func (r *raster) statLines(img *image.RGBA, f Face, y int, ink color.RGBA) {
+	label(img, 5, y, 1, ink, anchorStart, fmt.Sprintf("ATK %d", f.Atk))
+	if !f.Link {
+		label(img, 5, y+12, 1, ink, anchorStart, fmt.Sprintf("DEF %d", f.Def))
+	}
+}
+
+// mix returns the color halfway between a and b.
+func mix(a, b color.RGBA) color.RGBA {
+	return color.RGBA{uint8((int(a.R) + int(b.R)) / 2), uint8((int(a.G) + int(b.G)) / 2), uint8((int(a.B) + int(b.B)) / 2), 0xff}
+}
+
+func (r *raster) chain(s *scene) {
+	links := s.b.Chains.Current()
+	if s.chain.w == 0 {
+		return
+	}
+	p := s.chain
+	fillRect(r.img, p, colorPanel)
+	label(r.img, p.x+12, p.y+10, 2, inkLight, anchorStart, "Chain")
+	if len(links) == 0 {
+		return
+	}
+
+	top := s.b.Chains.Top()
+	if top != nil {
+		for _, t := range top.Targets {
+			if rc, ok := s.place(t); ok {
+				strokeRect(r.img, rect{rc.x - 2, rc.y - 2, rc.w + 4, rc.h + 4}, colorTarget, 3, 8)
+			}
+		}
+	}
+	for _, l := range links {
+		if rc, ok := s.place(l.CardPlace); ok {
+			r.badge(rc.x+12, rc.y+rc.h-12, chainColor(l), fmt.Sprint(l.Number))
+		}
+	}
+
+	y := p.y + 40
+	for i := len(links) - 1; i >= 0; i-- {
+		l := links[i]
+		if y+36 > p.y+p.h {
+			break
+		}
+		r.badge(p.x+22, y+4, chainColor(l), fmt.Sprint(l.Number))
+		name := strings.TrimRight(fit(CardName(l.Code, s.db), 38, unicodeBox.more), " ")
+		if l == top {
+			name = "> " + name
+		}
+		label(r.img, p.x+40, y-2, 1, inkLight, anchorStart, name)
+		label(r.img, p.x+40, y+12, 1, colorSubtle, anchorStart, linkStatus(l))
+		y += 40
+	}
+}
+
+/*
+   Artwork
+*/
+
+// Artwork loads card pictures named by code from a directory, as in
+// EDOPro's pics folder: 89631139.jpg or 89631139.png. Pictures are scaled
+// to the card size once and kept.
+type Artwork struct {
+	dir   string
+	cache map[uint32]*image.RGBA
+}
+
+func NewArtwork(dir string) *Artwork {
+	return &Artwork{dir: dir, cache: make(map[uint32]*image.RGBA)}
+}
+
+// Get returns the picture for code scaled to w x h, or nil if there is
+// none. A nil Artwork has no pictures.
+func (a *Artwork) Get(code uint32, w, h int) *image.RGBA {
+	if a == nil || code == 0 {
+		return nil
+	}
+	if pic, ok := a.cache[code]; ok {
+		return pic
+	}
+	var pic *image.RGBA
+	for _, ext := range []string{".jpg", ".png"} {
+		if src, err := loadImage(filepath.Join(a.dir, fmt.Sprint(code)+ext)); err == nil {
+			pic = scale(src, w, h)
+			break
+		}
+	}
+	a.cache[code] = pic
+	return pic
+}
+
+func loadImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return img, nil
+}
+
+// scale resizes src to w x h, averaging the source pixels under each
+// destination pixel.
+func scale(src image.Image, w, h int) *image.RGBA {
+	sb := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0 := sb.Min.Y + y*sb.Dy()/h
+		y1 := max(y0+1, sb.Min.Y+(y+1)*sb.Dy()/h)
+		for x := 0; x < w; x++ {
+			x0 := sb.Min.X + x*sb.Dx()/w
+			x1 := max(x0+1, sb.Min.X+(x+1)*sb.Dx()/w)
+			var rs, gs, bs, n uint32
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, _ := src.At(sx, sy).RGBA()
+					rs, gs, bs, n = rs+cr, gs+cg, bs+cb, n+1
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{uint8(rs / n >> 8), uint8(gs / n >> 8), uint8(bs / n >> 8), 0xff})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,269 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/spb8026/ygo-visualizer/carddb"
+	"github.com/spb8026/ygo-visualizer/narrate"
+	"github.com/spb8026/ygo-visualizer/state"
+	duelpb "github.com/spb8026/ygo-visualizer/ygopenpb"
+)
+
+// Geometry of a board picture, in SVG user units. Raster frames draw one
+// unit per pixel.
+const (
+	cellW   = 140
+	cellH   = 140
+	cardW   = 92
+	cardH   = 132
+	gap     = 8
+	margin  = 16
+	headerH = 28
+	barH    = 48
+	lpBarW  = 200
+	chainW  = 300
+
+	// fullLP is the LP a full bar stands for; more LP still fills it.
+	fullLP = 8000
+)
+
+type rect struct {
+	x, y, w, h int
+}
+
+func (r rect) cx() int { return r.x + r.w/2 }
+func (r rect) cy() int { return r.y + r.h/2 }
+
+// centered returns a w x h rect centered in r.
+func (r rect) centered(w, h int) rect {
+	return rect{r.cx() - w/2, r.cy() - h/2, w, h}
+}
+
+// scene places everything a picture of a board shows: the header, both
+// players' bars and hands, the zone grid and the chain panel.
+type scene struct {
+	b      *state.Board
+	db     carddb.Source
+	bottom int
+	grid   [Rows][Cols]*Slot
+
+	width, height int
+	header        rect
+	bars          [2]rect // by controller
+	hands         [2]rect // by controller; zero when hands are omitted
+	gridX         int
+	rowY, rowH    [Rows]int
+	chain         rect // zero when the panel is not shown
+}
+
+// newScene lays out b. The chain panel is shown while a chain is open,
+// or always with reserveChain so that every frame has the same width.
+func newScene(b *state.Board, db carddb.Source, bottom int, hands, reserveChain bool) *scene {
+	s := &scene{b: b, db: db, bottom: bottom, grid: Grid(b), gridX: margin}
+	gridW := Cols*cellW + (Cols-1)*gap
+	top := 1 - bottom
+
+	y := margin
+	s.header = rect{margin, y, gridW, headerH}
+	y += headerH + gap
+	if hands {
+		s.hands[top] = rect{margin, y, gridW, cardH}
+		y += cardH + gap
+	}
+	s.bars[top] = rect{margin, y, gridW, barH}
+	y += barH + gap
+	for r := 0; r < Rows; r++ {
+		s.rowY[r] = y
+		if r == RowEMZ && !b.HasEMZ() {
+			continue
+		}
+		s.rowH[r] = cellH
+		y += cellH + gap
+	}
+	s.bars[bottom] = rect{margin, y, gridW, barH}
+	y += barH + gap
+	if hands {
+		s.hands[bottom] = rect{margin, y, gridW, cardH}
+		y += cardH + gap
+	}
+	s.height = y - gap + margin
+
+	s.width = margin + gridW + margin
+	if reserveChain || len(b.Chains.Current()) > 0 {
+		s.chain = rect{s.width, s.bars[top].y, chainW, s.bars[bottom].y + barH - s.bars[top].y}
+		s.width += chainW + margin
+	}
+	return s
+}
+
+// cell returns the rect of grid cell row, col in player 0's view.
+func (s *scene) cell(row, col int) rect {
+	if s.bottom == 1 {
+		row, col = Rows-1-row, Cols-1-col
+	}
+	return rect{s.gridX + col*(cellW+gap), s.rowY[row], cellW, s.rowH[row]}
+}
+
+// handCard returns the rect of card i of n in con's hand. Cards overlap
+// when they do not fit side by side.
+func (s *scene) handCard(con, i, n int) rect {
+	r := s.hands[con]
+	step := cardW + gap
+	if n > 1 && (n-1)*step+cardW > r.w {
+		step = (r.w - cardW) / (n - 1)
+	}
+	return rect{r.x + i*step, r.y, cardW, cardH}
+}
+
+// place returns the rect a place is drawn in, if the picture shows it.
+func (s *scene) place(p *duelpb.Place) (rect, bool) {
+	con, loc, seq := int(p.GetCon()&1), p.GetLoc(), int(p.GetSeq())
+	if loc == state.LOC_HAND {
+		n := len(s.b.Players[con].Hand)
+		if s.hands[con].w == 0 || seq >= n {
+			return rect{}, false
+		}
+		return s.handCard(con, seq, n), true
+	}
+	for _, slot := range Slots(s.b) {
+		if slot.Con != con || slot.Loc != loc {
+			continue
+		}
+		if slot.Pile() || slot.Seq == seq {
+			return s.cell(slot.Row, slot.Col), true
+		}
+	}
+	return rect{}, false
+}
+
+// counterAt is where the i-th counter badge of a card drawn in box goes:
+// down the right edge of an upright card, between the name and the stats,
+// or across the middle of a sideways one.
+func counterAt(box rect, f Face, i int) (x, y int) {
+	if f.Monster && f.Defense {
+		return box.cx() + 8 + 22*i, box.cy()
+	}
+	return box.x + box.w - 10, box.y + 78 + 22*i
+}
+
+// frame is the fill and text color of a card.
+type frame struct {
+	fill, ink color.RGBA
+}
+
+var (
+	inkDark  = color.RGBA{0x1a, 0x1a, 0x1a, 0xff}
+	inkLight = color.RGBA{0xf5, 0xf5, 0xf5, 0xff}
+
+	frameBack    = frame{color.RGBA{0x8a, 0x5a, 0x2b, 0xff}, inkLight}
+	frameUnknown = frame{color.RGBA{0xb8, 0xb8, 0xb8, 0xff}, inkDark}
+)
+
+// frameOf picks the frame color the printed card has.
+func frameOf(f Face) frame {
+	t := f.Type
+	switch {
+	case f.FaceDown:
+		return frameBack
+	case t == 0:
+		return frameUnknown
+	case t&carddb.TYPE_SPELL != 0:
+		return frame{color.RGBA{0x2f, 0x9e, 0x8f, 0xff}, inkLight}
+	case t&carddb.TYPE_TRAP != 0:
+		return frame{color.RGBA{0xb5, 0x48, 0x7f, 0xff}, inkLight}
+	case t&carddb.TYPE_TOKEN != 0:
+		return frameUnknown
+	case t&carddb.TYPE_LINK != 0:
+		return frame{color.RGBA{0x2e, 0x5f, 0xa8, 0xff}, inkLight}
+	case t&carddb.TYPE_XYZ != 0:
+		return frame{color.RGBA{0x3a, 0x3a, 0x3a, 0xff}, inkLight}
+	case t&carddb.TYPE_SYNCHRO != 0:
+		return frame{color.RGBA{0xe6, 0xe6, 0xe6, 0xff}, inkDark}
+	case t&carddb.TYPE_FUSION != 0:
+		return frame{color.RGBA{0xa0, 0x7c, 0xc5, 0xff}, inkDark}
+	case t&carddb.TYPE_RITUAL != 0:
+		return frame{color.RGBA{0x7f, 0xa6, 0xd9, 0xff}, inkDark}
+	case t&carddb.TYPE_NORMAL != 0:
+		return frame{color.RGBA{0xe8, 0xc7, 0x7a, 0xff}, inkDark}
+	}
+	return frame{color.RGBA{0xd9, 0x8a, 0x4a, 0xff}, inkDark}
+}
+
+// lpColor is green above half of fullLP, amber above a quarter, else red.
+func lpColor(lp uint32) color.RGBA {
+	switch {
+	case lp > fullLP/2:
+		return color.RGBA{0x3c, 0xa0, 0x4a, 0xff}
+	case lp > fullLP/4:
+		return color.RGBA{0xe0, 0xa0, 0x30, 0xff}
+	}
+	return color.RGBA{0xd0, 0x3a, 0x3a, 0xff}
+}
+
+// lpWidth is the filled width of an LP bar w units long.
+func lpWidth(lp uint32, w int) int {
+	return int(min(lp, fullLP)) * w / fullLP
+}
+
+// chainColor is orange for links still to resolve and grey for the rest.
+func chainColor(l *state.Link) color.RGBA {
+	if l.Outcome != state.OutcomePending {
+		return color.RGBA{0x70, 0x78, 0x80, 0xff}
+	}
+	return color.RGBA{0xe0, 0x7a, 0x20, 0xff}
+}
+
+// linkStatus is the line under a chain link's name: its controller, then
+// how it resolved and how many cards it targets.
+func linkStatus(l *state.Link) string {
+	status := narrate.PlayerName(l.Controller)
+	switch {
+	case l.Outcome != state.OutcomePending:
+		status += " · " + l.Outcome.String()
+	case l.Solving:
+		status += " · resolving"
+	}
+	if len(l.Targets) > 0 {
+		status += fmt.Sprintf(" · %d targets", len(l.Targets))
+	}
+	return status
+}
+
+// wrapCols breaks s into at most lines lines of cols columns, preferring
+// spaces, and marks a cut with an ellipsis.
+func wrapCols(s string, cols, lines int) []string {
+	var out []string
+	words := strings.Fields(s)
+	cur := ""
+	for len(words) > 0 {
+		w := words[0]
+		next := w
+		if cur != "" {
+			next = cur + " " + w
+		}
+		switch {
+		case textWidth(next) <= cols:
+			cur = next
+			words = words[1:]
+			continue
+		case cur == "":
+			// A word longer than a line is broken inside.
+			head := strings.TrimRight(fit(w, cols, ""), " ")
+			cur = head
+			words[0] = w[len(head):]
+		}
+		out = append(out, cur)
+		cur = ""
+		if len(out) == lines {
+			last := out[lines-1]
+			out[lines-1] = strings.TrimRight(fit(last+" "+strings.Join(words, " "), cols, unicodeBox.more), " ")
+			return out
+		}
+	}
+	if cur != "" {
+		out = append(out, cur)
+	}
+	return out
+}
@@ -0,0 +1,262 @@
+package render
+
+import (
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/spb8026/ygo-visualizer/carddb"
+	"github.com/spb8026/ygo-visualizer/narrate"
+	"github.com/spb8026/ygo-visualizer/state"
+)
+
+// SVGOptions controls SVG.
+type SVGOptions struct {
+	// DB names cards and colors their frames; nil shows codes.
+	DB carddb.Source
+	// Bottom is the player drawn at the bottom of the field.
+	Bottom int
+	// OmitHands leaves out both hands.
+	OmitHands bool
+}
+
+// SVG draws b as a standalone SVG document: LP bars, the zone grid for
+// the board's field shape, and a panel listing the open chain. Defense
+// position monsters are turned sideways, face-down cards show their back,
+// and badges count Xyz materials and counters. Chain links are numbered
+// on their cards and their targets are outlined.
+func SVG(b *state.Board, opts SVGOptions) string {
+	s := newScene(b, opts.DB, opts.Bottom, !opts.OmitHands, false)
+	w := &svgWriter{}
+	w.printf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="11">`+"\n",
+		s.width, s.height, s.width, s.height)
+	w.printf(`<rect width="%d" height="%d" fill="#20252b"/>`+"\n", s.width, s.height)
+
+	w.text(s.header.x, s.header.y+18, 15, inkLight, "start", "bold",
+		fmt.Sprintf("Turn %d · %s's turn · %s", b.Turn, narrate.PlayerName(b.TurnPlayer), narrate.PhaseName(b.Phase)))
+	for con := 0; con < 2; con++ {
+		w.bar(s, con)
+		if s.hands[con].w > 0 {
+			hand := b.Players[con].Hand
+			for i, c := range hand {
+				w.card(s, s.handCard(con, i, len(hand)), HeldFace(c, s.db), nil)
+			}
+		}
+	}
+	for r := 0; r < Rows; r++ {
+		for c := 0; c < Cols; c++ {
+			if slot := s.grid[r][c]; slot != nil && s.rowH[r] > 0 {
+				w.slot(s, slot)
+			}
+		}
+	}
+	w.chain(s)
+	w.printf("</svg>\n")
+	return w.sb.String()
+}
+
+type svgWriter struct {
+	sb strings.Builder
+}
+
+func (w *svgWriter) printf(format string, args ...any) {
+	fmt.Fprintf(&w.sb, format, args...)
+}
+
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func (w *svgWriter) text(x, y, size int, ink color.RGBA, anchor, weight, s string) {
+	w.printf(`<text x="%d" y="%d" font-size="%d" fill="%s" text-anchor="%s" font-weight="%s">`, x, y, size, hex(ink), anchor, weight)
+	xml.EscapeText(&w.sb, []byte(s))
+	w.printf("</text>\n")
+}
+
+func (w *svgWriter) title(s string) {
+	w.printf("<title>")
+	xml.EscapeText(&w.sb, []byte(s))
+	w.printf("</title>")
+}
+
+// badge draws a small numbered circle, with a tooltip.
+func (w *svgWriter) badge(x, y int, fill color.RGBA, label, tip string) {
+	w.printf(`<g>`)
+	w.title(tip)
+	w.printf(`<circle cx="%d" cy="%d" r="10" fill="%s" stroke="#ffffff" stroke-width="1.5"/>`, x, y, hex(fill))
+	w.text(x, y+4, 11, inkLight, "middle", "bold", label)
+	w.printf("</g>\n")
+}
+
+func (w *svgWriter) bar(s *scene, con int) {
+	r := s.bars[con]
+	p := s.b.Players[con]
+	weight := "normal"
+	if s.b.TurnPlayer == con {
+		weight = "bold"
+	}
+	w.printf(`<rect x="%d" y="%d" width="%d" height="%d" rx="6" fill="#2d343c"/>`+"\n", r.x, r.y, r.w, r.h)
+	w.text(r.x+12, r.y+20, 14, inkLight, "start", weight, narrate.PlayerName(con))
+	w.text(r.x+12, r.y+38, 11, color.RGBA{0xb0, 0xb8, 0xc0, 0xff}, "start", "normal",
+		fmt.Sprintf("Hand %d · Deck %d · Extra %d · GY %d · Banished %d",
+			len(p.Hand), len(p.Deck), len(p.Extra), len(p.Grave), len(p.Banished)))
+
+	bx, by := r.x+r.w-lpBarW-12, r.y+24
+	w.text(bx-8, by+10, 14, inkLight, "end", "bold", fmt.Sprintf("LP %d", p.LP))
+	w.printf(`<rect x="%d" y="%d" width="%d" height="12" rx="3" fill="#4a525c"/>`+"\n", bx, by, lpBarW)
+	w.printf(`<rect x="%d" y="%d" width="%d" height="12" rx="3" fill="%s"/>`+"\n", bx, by, lpWidth(p.LP, lpBarW), hex(lpColor(p.LP)))
+}
+
+// slot draws one grid cell: the zone outline and label, then its card or
+// pile.
+func (w *svgWriter) slot(s *scene, slot *Slot) {
+	r := s.cell(slot.Row, slot.Col)
+	b := s.b
+	w.printf(`<rect x="%d" y="%d" width="%d" height="%d" rx="6" fill="none" stroke="#4a525c" stroke-dasharray="4 3"/>`+"\n", r.x, r.y, r.w, r.h)
+	w.text(r.x+6, r.y+14, 10, color.RGBA{0x80, 0x88, 0x90, 0xff}, "start", "normal", slot.Label(b))
+
+	card := slot.Card(b)
+	if slot.Pile() {
+		n := slot.Count(b)
+		if card == nil {
+			return
+		}
+		box := r.centered(cardW, cardH)
+		f := HeldFace(card, s.db)
+		if slot.Loc != state.LOC_GRAVE {
+			f.FaceDown = f.FaceDown || card.Position&state.POS_FACEDOWN != 0
+		}
+		// Thicker piles get more edges drawn behind the top card.
+		for i := min(n-1, 3); i > 0; i-- {
+			w.printf(`<rect x="%d" y="%d" width="%d" height="%d" rx="4" fill="#5a4020" stroke="#20252b"/>`+"\n",
+				box.x+2*i, box.y+2*i, box.w, box.h)
+		}
+		w.card(s, box, f, nil)
+		w.badge(box.x+box.w-4, box.y+box.h-4, color.RGBA{0x50, 0x58, 0x60, 0xff}, fmt.Sprint(n), fmt.Sprintf("%s: %d cards", slot.Label(b), n))
+		return
+	}
+	if card == nil {
+		return
+	}
+
+	f := FaceOf(card, slot.Loc == state.LOC_MZONE, s.db)
+	box := r.centered(cardW, cardH)
+	if n := slot.Count(b); n > 0 {
+		// Materials peek out from under the Xyz monster.
+		for i := min(n, 3); i > 0; i-- {
+			w.printf(`<rect x="%d" y="%d" width="%d" height="%d" rx="4" fill="#3a3a3a" stroke="#8a8a8a" transform="rotate(%d %d %d)"/>`+"\n",
+				box.x, box.y, box.w, box.h, 4*i, r.cx(), r.cy())
+		}
+	}
+	w.card(s, box, f, card.Counters)
+	if n := slot.Count(b); n > 0 {
+		w.badge(r.x+r.w-12, r.y+12, color.RGBA{0x3a, 0x3a, 0x3a, 0xff}, fmt.Sprintf("×%d", n), fmt.Sprintf("%d Xyz materials", n))
+	}
+}
+
+// card draws one card in box, turned sideways in Defense Position.
+func (w *svgWriter) card(s *scene, box rect, f Face, counters []state.Counter) {
+	fr := frameOf(f)
+	tip := f.Name
+	if f.Code == 0 {
+		tip = "face-down card"
+	}
+	w.printf(`<g>`)
+	w.title(tip)
+	if f.Monster && f.Defense {
+		w.printf(`<g transform="rotate(-90 %d %d)">`, box.cx(), box.cy())
+	} else {
+		w.printf(`<g>`)
+	}
+	w.printf(`<rect x="%d" y="%d" width="%d" height="%d" rx="4" fill="%s" stroke="#101214" stroke-width="1.5"/>`+"\n",
+		box.x, box.y, box.w, box.h, hex(fr.fill))
+
+	if f.FaceDown {
+		w.printf(`<ellipse cx="%d" cy="%d" rx="%d" ry="%d" fill="none" stroke="#c89050" stroke-width="3"/>`+"\n",
+			box.cx(), box.cy(), box.w/3, box.h/4)
+		if f.Code == 0 {
+			w.printf("</g></g>\n")
+			return
+		}
+	}
+
+	// Name at the top, then the Level or Rank, then the stats at the
+	// bottom. A face-down card its owner knows is still labelled, dimmed.
+	opacity := 1.0
+	if f.FaceDown {
+		opacity = 0.6
+	}
+	w.printf(`<g opacity="%.1f">`, opacity)
+	for i, line := range wrapCols(f.Name, 15, 3) {
+		w.text(box.x+5, box.y+15+13*i, 11, fr.ink, "start", "bold", line)
+	}
+	if f.Monster && f.Type != 0 {
+		rating := fmt.Sprintf("★%d", f.Rating)
+		switch {
+		case f.Link:
+			rating = fmt.Sprintf("LINK-%d", f.Rating)
+		case f.Type&carddb.TYPE_XYZ != 0:
+			rating = fmt.Sprintf("☆%d", f.Rating)
+		}
+		w.text(box.x+box.w-5, box.y+62, 10, fr.ink, "end", "normal", rating)
+	}
+	if f.Monster && f.Code != 0 {
+		w.text(box.x+5, box.y+box.h-20, 10, fr.ink, "start", "bold", fmt.Sprintf("ATK %d", f.Atk))
+		if !f.Link {
+			w.text(box.x+5, box.y+box.h-7, 10, fr.ink, "start", "bold", fmt.Sprintf("DEF %d", f.Def))
+		}
+	}
+	w.printf("</g></g>\n")
+
+	for i, ctr := range counters {
+		x, y := counterAt(box, f, i)
+		w.badge(x, y, color.RGBA{0x2e, 0x7d, 0xd0, 0xff}, fmt.Sprint(ctr.Count),
+			fmt.Sprintf("%d counters of type 0x%x", ctr.Count, ctr.Type))
+	}
+	w.printf("</g>\n")
+}
+
+// chain numbers each link on the card that activated it, outlines the
+// targets of the link about to resolve, and lists the chain in a panel.
+func (w *svgWriter) chain(s *scene) {
+	links := s.b.Chains.Current()
+	if len(links) == 0 {
+		return
+	}
+	top := s.b.Chains.Top()
+	if top != nil {
+		for _, t := range top.Targets {
+			if r, ok := s.place(t); ok {
+				w.printf(`<rect x="%d" y="%d" width="%d" height="%d" rx="6" fill="none" stroke="#ff5050" stroke-width="3" stroke-dasharray="8 4"/>`+"\n",
+					r.x-2, r.y-2, r.w+4, r.h+4)
+			}
+		}
+	}
+	for _, l := range links {
+		if r, ok := s.place(l.CardPlace); ok {
+			w.badge(r.x+12, r.y+r.h-12, chainColor(l), fmt.Sprint(l.Number), fmt.Sprintf("Chain Link %d", l.Number))
+		}
+	}
+
+	p := s.chain
+	w.printf(`<rect x="%d" y="%d" width="%d" height="%d" rx="6" fill="#2d343c" opacity="0.95"/>`+"\n", p.x, p.y, p.w, p.h)
+	w.text(p.x+12, p.y+22, 14, inkLight, "start", "bold", "Chain")
+	y := p.y + 46
+	// The newest link resolves first, so it is listed first.
+	for i := len(links) - 1; i >= 0; i-- {
+		l := links[i]
+		if y+36 > p.y+p.h {
+			break
+		}
+		w.badge(p.x+22, y-4, chainColor(l), fmt.Sprint(l.Number), fmt.Sprintf("Chain Link %d", l.Number))
+		weight := "normal"
+		if l == top {
+			weight = "bold"
+		}
+		name := strings.TrimRight(fit(CardName(l.Code, s.db), 32, unicodeBox.more), " ")
+		w.text(p.x+40, y, 12, inkLight, "start", weight, name)
+		w.text(p.x+40, y+15, 10, color.RGBA{0xb0, 0xb8, 0xc0, 0xff}, "start", "normal", linkStatus(l))
+		y += 40
+	}
+}
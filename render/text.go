@@ -0,0 +1,290 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spb8026/ygo-visualizer/carddb"
+	"github.com/spb8026/ygo-visualizer/narrate"
+	"github.com/spb8026/ygo-visualizer/state"
+)
+
+// TextOptions controls Text.
+type TextOptions struct {
+	// Width is the number of terminal columns; 0 means 80.
+	Width int
+	// DB names cards; nil shows codes.
+	DB carddb.Source
+	// Bottom is the player drawn at the bottom of the field.
+	Bottom int
+	// ASCII draws boxes with +-| instead of box-drawing characters.
+	ASCII bool
+	// Compact drops cell borders even when there is room for them, for
+	// short terminals.
+	Compact bool
+	// OmitHands leaves out the hand lines, for callers that show hands
+	// elsewhere.
+	OmitHands bool
+}
+
+// Cells narrower than this drop their borders.
+const minBoxedCell = 6
+
+// maxCell keeps cells readable on very wide terminals.
+const maxCell = 18
+
+type boxChars struct {
+	h, v, tl, tr, bl, br, more string
+}
+
+var (
+	unicodeBox = boxChars{"─", "│", "┌", "┐", "└", "┘", "…"}
+	asciiBox   = boxChars{"-", "|", "+", "+", "+", "+", "~"}
+)
+
+// Text draws b for a terminal: both players' LP, pile counts and hands,
+// the zone grid and the open chain. A monster's second line reads
+// "x2 A 2500/2000": Xyz materials, A(ttack), D(efense) or SET, then ATK/DEF.
+// Lines never exceed opts.Width columns.
+func Text(b *state.Board, opts TextOptions) string {
+	width := opts.Width
+	if width <= 0 {
+		width = 80
+	}
+	box := unicodeBox
+	if opts.ASCII {
+		box = asciiBox
+	}
+	t := &textRenderer{b: b, db: opts.DB, width: width, box: box, hands: !opts.OmitHands}
+
+	cw := (width - 3*Cols + 1) / Cols
+	t.boxed = cw >= minBoxedCell && !opts.Compact
+	if !t.boxed {
+		cw = (width - Cols + 1) / Cols
+	}
+	t.cell = max(1, min(cw, maxCell))
+
+	top, bottom := 1-opts.Bottom, opts.Bottom
+	t.line(fmt.Sprintf("Turn %d · %s's turn · %s", b.Turn, narrate.PlayerName(b.TurnPlayer), narrate.PhaseName(b.Phase)))
+	t.player(top)
+	t.grid(opts.Bottom == 1)
+	t.player(bottom)
+	t.chain()
+	return t.sb.String()
+}
+
+type textRenderer struct {
+	b     *state.Board
+	db    carddb.Source
+	width int
+	box   boxChars
+	boxed bool
+	hands bool
+	cell  int
+	sb    strings.Builder
+}
+
+func (t *textRenderer) line(s string) {
+	t.sb.WriteString(strings.TrimRight(fit(s, t.width, t.box.more), " "))
+	t.sb.WriteByte('\n')
+}
+
+func (t *textRenderer) player(con int) {
+	p := t.b.Players[con]
+	t.line(fmt.Sprintf("%s  LP %d  Hand %d  Deck %d  Extra %d  GY %d  Banished %d",
+		narrate.PlayerName(con), p.LP, len(p.Hand), len(p.Deck), len(p.Extra), len(p.Grave), len(p.Banished)))
+	if len(p.Hand) == 0 || !t.hands {
+		return
+	}
+	var names []string
+	for _, c := range p.Hand {
+		names = append(names, CardName(c.Code, t.db))
+	}
+	t.line("  Hand: " + strings.Join(names, ", "))
+}
+
+func (t *textRenderer) grid(flip bool) {
+	grid := Grid(t.b)
+	for r := 0; r < Rows; r++ {
+		var cells [Cols][2]string
+		var used [Cols]bool
+		for c := 0; c < Cols; c++ {
+			s := grid[r][c]
+			if flip {
+				s = grid[Rows-1-r][Cols-1-c]
+			}
+			if s != nil {
+				cells[c] = t.slotLines(s)
+				used[c] = true
+			}
+		}
+		if r == RowEMZ && !t.b.HasEMZ() {
+			t.sb.WriteByte('\n')
+			continue
+		}
+		t.row(cells, used)
+	}
+}
+
+// row writes one grid row: each cell is two text lines, boxed if there is
+// room.
+func (t *textRenderer) row(cells [Cols][2]string, used [Cols]bool) {
+	cw := t.cell
+	edge := func(left, right string) {
+		var parts []string
+		for c := range cells {
+			if used[c] {
+				parts = append(parts, left+strings.Repeat(t.box.h, cw)+right)
+			} else {
+				parts = append(parts, strings.Repeat(" ", cw+2))
+			}
+		}
+		t.line(strings.Join(parts, " "))
+	}
+	if t.boxed {
+		edge(t.box.tl, t.box.tr)
+	}
+	for i := 0; i < 2; i++ {
+		var parts []string
+		for c, cell := range cells {
+			text := fit(cell[i], cw, t.box.more)
+			switch {
+			case !t.boxed:
+				parts = append(parts, text)
+			case used[c]:
+				parts = append(parts, t.box.v+text+t.box.v)
+			default:
+				parts = append(parts, "  "+text)
+			}
+		}
+		sep := " "
+		if !t.boxed {
+			sep = t.box.v
+		}
+		t.line(strings.Join(parts, sep))
+	}
+	if t.boxed {
+		edge(t.box.bl, t.box.br)
+	}
+}
+
+// slotLines returns the two lines drawn inside a slot.
+func (t *textRenderer) slotLines(s *Slot) [2]string {
+	b := t.b
+	card := s.Card(b)
+	if s.Pile() {
+		top := ""
+		if card != nil && s.Loc == state.LOC_GRAVE {
+			top = CardName(card.Code, t.db)
+		}
+		return [2]string{fmt.Sprintf("%s %d", s.Label(b), s.Count(b)), top}
+	}
+	if card == nil {
+		return [2]string{s.Label(b), ""}
+	}
+
+	f := FaceOf(card, s.Loc == state.LOC_MZONE, t.db)
+	name := f.Name
+	if f.Code == 0 {
+		name = s.Label(b)
+	}
+	// Materials and position come first so narrow cells cut DEF instead.
+	var status []string
+	if n := s.Count(b); n > 0 {
+		status = append(status, fmt.Sprintf("x%d", n))
+	}
+	switch {
+	case f.FaceDown && f.Monster:
+		status = append(status, "SET")
+	case f.FaceDown:
+		status = append(status, "set")
+	case f.Monster && f.Defense:
+		status = append(status, "D")
+	case f.Monster:
+		status = append(status, "A")
+	}
+	if stats := f.Stats(); stats != "" {
+		status = append(status, stats)
+	}
+	return [2]string{name, strings.Join(status, " ")}
+}
+
+func (t *textRenderer) chain() {
+	links := t.b.Chains.Current()
+	if len(links) == 0 {
+		return
+	}
+	t.line("Chain:")
+	for _, l := range links {
+		s := fmt.Sprintf("  %d. %s (%s)", l.Number, CardName(l.Code, t.db), narrate.PlayerName(l.Controller))
+		if len(l.Targets) > 0 {
+			var targets []string
+			for _, p := range l.Targets {
+				targets = append(targets, narrate.ZoneName(p, t.b.SeparatePZones))
+			}
+			s += " → " + strings.Join(targets, ", ")
+		}
+		if l.Outcome != state.OutcomePending {
+			s += " [" + l.Outcome.String() + "]"
+		} else if l.Solving {
+			s += " [resolving]"
+		}
+		t.line(s)
+	}
+}
+
+// Fit pads or truncates s to exactly w terminal columns, marking cuts
+// with an ellipsis.
+func Fit(s string, w int) string {
+	return fit(s, w, unicodeBox.more)
+}
+
+// Width returns the number of terminal columns s takes.
+func Width(s string) int {
+	return textWidth(s)
+}
+
+// fit pads or truncates s to exactly w display columns, marking cuts with
+// more.
+func fit(s string, w int, more string) string {
+	if n := textWidth(s); n <= w {
+		return s + strings.Repeat(" ", w-n)
+	}
+	var sb strings.Builder
+	used := 0
+	for _, r := range s {
+		rw := runeWidth(r)
+		if used+rw > w-1 {
+			break
+		}
+		sb.WriteRune(r)
+		used += rw
+	}
+	sb.WriteString(more)
+	used++
+	return sb.String() + strings.Repeat(" ", max(0, w-used))
+}
+
+func textWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeWidth(r)
+	}
+	return n
+}
+
+// runeWidth is 2 for East Asian wide characters, which card names in the
+// Japanese and Chinese databases are made of, and 1 otherwise.
+func runeWidth(r rune) int {
+	switch {
+	case r >= 0x1100 && r <= 0x115f,
+		r >= 0x2e80 && r <= 0xa4cf,
+		r >= 0xac00 && r <= 0xd7a3,
+		r >= 0xf900 && r <= 0xfaff,
+		r >= 0xfe30 && r <= 0xfe4f,
+		r >= 0xff00 && r <= 0xff60,
+		r >= 0xffe0 && r <= 0xffe6:
+		return 2
+	}
+	return 1
+}
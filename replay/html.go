@@ -0,0 +1,153 @@
+package replay
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+
+	"github.com/spb8026/ygo-visualizer/carddb"
+	"github.com/spb8026/ygo-visualizer/fog"
+	"github.com/spb8026/ygo-visualizer/narrate"
+	"github.com/spb8026/ygo-visualizer/render"
+	"github.com/spb8026/ygo-visualizer/view"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+//go:embed viewer.html
+var viewerHTML string
+
+//go:embed viewer.js
+var viewerJS string
+
+var pageTemplate = template.Must(template.New("replay").Parse(viewerHTML))
+
+// HTMLOptions controls WriteHTML.
+type HTMLOptions struct {
+	// DB names cards and supplies their text; nil names them by code.
+	DB carddb.Source
+	// Lang is the narration language; "" means narrate.DefaultLanguage.
+	Lang  string
+	Title string
+}
+
+// viewers are the perspectives a page can be watched from, in the order
+// of its perspective buttons.
+var viewers = [...]fog.Viewer{fog.Spectator, fog.Player0, fog.Player1}
+
+// data is everything the page's script gets.
+type data struct {
+	Title     string           `json:"title"`
+	Viewers   []string         `json:"viewers"`
+	Cards     view.Cards       `json:"cards"`
+	Steps     []step           `json:"steps"`
+	Snapshots []*view.Snapshot `json:"snapshots"`
+}
+
+// step is the duel right after one event, once for each of viewers.
+type step struct {
+	Kind string `json:"kind"`
+	Turn int    `json:"turn"`
+	// Events is the event as fog lets each viewer see it.
+	Events [len(viewers)]json.RawMessage `json:"events"`
+	// Views index data.Snapshots.
+	Views [len(viewers)]int `json:"views"`
+	// Log is what each viewer is told, including about requests that
+	// came after the event.
+	Log [len(viewers)][]string `json:"log"`
+}
+
+// WriteHTML writes rec as a single HTML page that plays the duel back in a
+// browser with no network access: the events and the boards after each of
+// them from every perspective, the narration and the text of every card
+// shown are all embedded. Each perspective only gets what fog lets it see.
+func WriteHTML(w io.Writer, rec *Recording, opts HTMLOptions) error {
+	lang := opts.Lang
+	if lang == "" {
+		lang = narrate.DefaultLanguage
+	}
+	aud := narrate.NewAudience()
+	for _, v := range viewers {
+		if err := aud.Add(v, opts.DB, lang, narrate.Normal); err != nil {
+			return err
+		}
+	}
+
+	d := data{Title: opts.Title, Cards: make(view.Cards)}
+	if d.Title == "" {
+		d.Title = "Duel replay"
+	}
+	d.Viewers = []string{"Spectator", narrate.PlayerName(0), narrate.PlayerName(1)}
+
+	seen := make(map[string]int)
+	snap := func(v fog.Viewer) (int, error) {
+		s := view.Snap(aud.Board(v), opts.DB, aud.Catalog(v))
+		b, err := json.Marshal(s)
+		if err != nil {
+			return 0, err
+		}
+		if i, ok := seen[string(b)]; ok {
+			return i, nil
+		}
+		d.Cards.AddSnapshot(s, opts.DB)
+		seen[string(b)] = len(d.Snapshots)
+		d.Snapshots = append(d.Snapshots, s)
+		return len(d.Snapshots) - 1, nil
+	}
+
+	// Lines heard before the first event wait for it.
+	var pending [len(viewers)][]string
+	for i, m := range rec.Messages {
+		told, err := aud.Tell(m)
+		var log [len(viewers)][]string
+		for j, v := range viewers {
+			if err != nil {
+				log[j] = []string{fmt.Sprintf("board error: %v", err)}
+			} else {
+				log[j] = told[v].Lines
+			}
+		}
+
+		kind := render.EventKind(m)
+		if kind == "" {
+			for j := range viewers {
+				if n := len(d.Steps); n > 0 {
+					d.Steps[n-1].Log[j] = append(d.Steps[n-1].Log[j], log[j]...)
+				} else {
+					pending[j] = append(pending[j], log[j]...)
+				}
+			}
+			continue
+		}
+
+		st := step{Kind: kind, Turn: aud.Board(fog.Spectator).Turn}
+		for j, v := range viewers {
+			if t, ok := told[v]; ok {
+				if st.Events[j], err = protojson.Marshal(t.Msg); err != nil {
+					return fmt.Errorf("message %d: %w", i, err)
+				}
+			}
+			if st.Views[j], err = snap(v); err != nil {
+				return fmt.Errorf("message %d: %w", i, err)
+			}
+			st.Log[j] = append(pending[j], log[j]...)
+			pending[j] = nil
+		}
+		d.Steps = append(d.Steps, st)
+	}
+
+	payload, err := json.Marshal(&d)
+	if err != nil {
+		return err
+	}
+	return pageTemplate.Execute(w, map[string]any{
+		"Title":  d.Title,
+		"Style":  template.CSS(view.Style),
+		"Board":  template.JS(view.Script),
+		"Viewer": template.JS(viewerJS),
+		// json.Marshal escapes <, > and &, so the payload cannot close
+		// its script element.
+		"Data": template.JS(payload),
+	})
+}
@@ -0,0 +1,112 @@
+// Package replay keeps the messages of a finished duel so it can be watched
+// again, and exports it as a self-contained HTML page.
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spb8026/ygo-visualizer/deck"
+	"github.com/spb8026/ygo-visualizer/fog"
+	duelpb "github.com/spb8026/ygo-visualizer/ygopenpb"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Version is the recording file format written by Write.
+const Version = 1
+
+// Recording is a duel's global message stream, in the order the core sent
+// it, with what is known of how the duel was set up.
+type Recording struct {
+	Seed     [4]uint64
+	Decks    [2]*deck.Deck
+	Messages []*duelpb.Msg
+}
+
+// Add appends m. The recording keeps m, so it must not be changed after.
+func (r *Recording) Add(m *duelpb.Msg) {
+	r.Messages = append(r.Messages, m)
+}
+
+// file is the JSON form of a Recording. Messages use the protobuf JSON
+// mapping, so a recording is readable and survives new proto fields.
+// ConfirmedTo maps the index of each confirm that names its player, which
+// the JSON mapping drops, to that player.
+type file struct {
+	Version     int               `json:"version"`
+	Seed        [4]uint64         `json:"seed"`
+	Decks       [2]*deck.Deck     `json:"decks,omitempty"`
+	Messages    []json.RawMessage `json:"messages"`
+	ConfirmedTo map[int]int       `json:"confirmed_to,omitempty"`
+}
+
+// Write encodes r as JSON.
+func (r *Recording) Write(w io.Writer) error {
+	f := file{Version: Version, Seed: r.Seed, Decks: r.Decks}
+	for i, m := range r.Messages {
+		b, err := protojson.Marshal(m)
+		if err != nil {
+			return fmt.Errorf("message %d: %w", i, err)
+		}
+		f.Messages = append(f.Messages, b)
+		if confirm := m.GetEvent().GetMeta().GetConfirm(); confirm != nil {
+			if to, ok := fog.ConfirmedTo(confirm); ok {
+				if f.ConfirmedTo == nil {
+					f.ConfirmedTo = make(map[int]int)
+				}
+				f.ConfirmedTo[i] = to
+			}
+		}
+	}
+	return json.NewEncoder(w).Encode(&f)
+}
+
+// Read decodes a recording written by Write.
+func Read(rd io.Reader) (*Recording, error) {
+	var f file
+	if err := json.NewDecoder(rd).Decode(&f); err != nil {
+		return nil, fmt.Errorf("decode recording: %w", err)
+	}
+	if f.Version != Version {
+		return nil, fmt.Errorf("unsupported recording version %d", f.Version)
+	}
+	r := &Recording{Seed: f.Seed, Decks: f.Decks}
+	for i, b := range f.Messages {
+		m := new(duelpb.Msg)
+		if err := protojson.Unmarshal(b, m); err != nil {
+			return nil, fmt.Errorf("message %d: %w", i, err)
+		}
+		if confirm := m.GetEvent().GetMeta().GetConfirm(); confirm != nil {
+			if to, ok := f.ConfirmedTo[i]; ok {
+				fog.SetConfirmedTo(confirm, to)
+			}
+		}
+		r.Messages = append(r.Messages, m)
+	}
+	return r, nil
+}
+
+// Save writes r to path.
+func (r *Recording) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := r.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load reads a recording from path.
+func Load(path string) (*Recording, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
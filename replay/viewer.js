@@ -0,0 +1,153 @@
+// viewer.js plays back the duel embedded in a replay page, drawing boards
+// with YGOView.
+"use strict";
+
+(function () {
+  // LOG_LINES is how much narration the log pane keeps.
+  var LOG_LINES = 300;
+
+  var data = JSON.parse(document.getElementById("replay-data").textContent);
+  var steps = data.steps;
+  var $ = function (id) { return document.getElementById(id); };
+  var board = $("board"), log = $("log"), event = $("event");
+  var scrub = $("scrub"), position = $("position"), play = $("play");
+  var turnSelect = $("turn"), speed = $("speed");
+
+  var cur = 0, viewer = 0, timer = null;
+
+  // turnStarts maps each turn to its first step.
+  var turnStarts = [];
+  steps.forEach(function (st, i) {
+    if (!turnStarts.length || turnStarts[turnStarts.length - 1].turn !== st.turn) {
+      turnStarts.push({ turn: st.turn, step: i });
+    }
+  });
+  turnStarts.forEach(function (t) {
+    var o = document.createElement("option");
+    o.value = t.step;
+    o.textContent = t.turn ? "Turn " + t.turn : "Setup";
+    turnSelect.appendChild(o);
+  });
+
+  data.viewers.forEach(function (name, i) {
+    var b = document.createElement("button");
+    b.textContent = name;
+    b.addEventListener("click", function () {
+      viewer = i;
+      show();
+    });
+    $("perspective").appendChild(b);
+  });
+
+  function line(text, cls) {
+    var d = document.createElement("div");
+    if (cls) d.className = cls;
+    d.textContent = text;
+    return d;
+  }
+
+  // showLog fills the log with what the viewer was told up to the
+  // current step, newest last.
+  function showLog() {
+    var blocks = [], n = 0;
+    for (var i = cur; i >= 0 && n < LOG_LINES; i--) {
+      var lines = steps[i].log[viewer] || [];
+      blocks.push({ i: i, lines: lines });
+      n += lines.length;
+    }
+    log.textContent = "";
+    for (var k = blocks.length - 1; k >= 0; k--) {
+      var b = blocks[k];
+      if (b.i > 0 && steps[b.i].turn !== steps[b.i - 1].turn) {
+        log.appendChild(line("Turn " + steps[b.i].turn, "turn"));
+      }
+      b.lines.forEach(function (text) {
+        log.appendChild(line(text, b.i === cur ? "latest" : ""));
+      });
+    }
+    log.scrollTop = log.scrollHeight;
+  }
+
+  function show() {
+    if (!steps.length) {
+      board.textContent = "The recording has no events.";
+      return;
+    }
+    var st = steps[cur];
+    var snap = data.snapshots[st.views[viewer]];
+    YGOView.draw(board, snap, data.cards, viewer === 2 ? 1 : 0);
+    scrub.value = cur;
+    position.textContent = "Event " + (cur + 1) + " of " + steps.length + " · " + st.kind;
+    for (var t = turnStarts.length - 1; t >= 0; t--) {
+      if (turnStarts[t].step <= cur) {
+        turnSelect.value = turnStarts[t].step;
+        break;
+      }
+    }
+    $("perspective").querySelectorAll("button").forEach(function (b, i) {
+      b.classList.toggle("active", i === viewer);
+    });
+    showLog();
+    var ev = st.events[viewer];
+    event.textContent = ev ? JSON.stringify(ev, null, 2) : "";
+  }
+
+  function go(i) {
+    cur = Math.max(0, Math.min(steps.length - 1, i));
+    show();
+  }
+
+  function pause() {
+    clearInterval(timer);
+    timer = null;
+    play.textContent = "▶";
+  }
+
+  function resume() {
+    if (cur >= steps.length - 1) go(0);
+    play.textContent = "⏸";
+    timer = setInterval(function () {
+      if (cur >= steps.length - 1) {
+        pause();
+        return;
+      }
+      go(cur + 1);
+    }, Number(speed.value));
+  }
+
+  function toggle() {
+    if (timer) pause();
+    else resume();
+  }
+
+  $("first").addEventListener("click", function () { go(0); });
+  $("back").addEventListener("click", function () { go(cur - 1); });
+  $("forward").addEventListener("click", function () { go(cur + 1); });
+  $("last").addEventListener("click", function () { go(steps.length - 1); });
+  play.addEventListener("click", toggle);
+  speed.addEventListener("change", function () {
+    if (timer) {
+      pause();
+      resume();
+    }
+  });
+  turnSelect.addEventListener("change", function () { go(Number(turnSelect.value)); });
+  scrub.max = Math.max(0, steps.length - 1);
+  scrub.addEventListener("input", function () { go(Number(scrub.value)); });
+  document.addEventListener("keydown", function (ev) {
+    if (ev.target.tagName === "SELECT" || ev.target.tagName === "INPUT") return;
+    switch (ev.key) {
+      case "ArrowLeft": go(cur - 1); break;
+      case "ArrowRight": go(cur + 1); break;
+      case "Home": go(0); break;
+      case "End": go(steps.length - 1); break;
+      case " ": toggle(); break;
+      default: return;
+    }
+    ev.preventDefault();
+  });
+
+  YGOView.tooltips(board, function () { return data.cards; });
+  document.title = data.title;
+  show();
+})();
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/spb8026/ygo-visualizer/prompt"
+	duelpb "github.com/spb8026/ygo-visualizer/ygopenpb"
+)
+
+// pass answers req for a player nobody controls. It declines whatever can
+// be declined, moves on to the next phase when idle, and otherwise takes
+// the first selection p accepts.
+func pass(req *duelpb.Msg_Request, p *prompt.Prompt) (*duelpb.Answer, error) {
+	if phases := req.GetSelectIdle().GetAvailablePhase(); phases != 0 {
+		idle := &duelpb.Answer_SelectIdle{T: &duelpb.Answer_SelectIdle_Phase{Phase: phases & -phases}}
+		return &duelpb.Answer{T: &duelpb.Answer_SelectIdle_{SelectIdle: idle}}, nil
+	}
+	if p.CanCancel {
+		return p.Cancel()
+	}
+
+	n := len(p.Options)
+	var tries [][]int
+	switch p.Mode {
+	case prompt.PickOne:
+		for i := 0; i < n; i++ {
+			tries = append(tries, []int{i})
+		}
+	case prompt.PickMany:
+		for k := max(p.Min, 1); k <= min(p.Max, n); k++ {
+			tries = append(tries, first(k))
+		}
+	case prompt.PickOrder:
+		tries = append(tries, first(n))
+	case prompt.PickAmounts:
+		amounts := make([]int, n)
+		left := p.Max
+		for i, o := range p.Options {
+			amounts[i] = min(o.Limit, left)
+			left -= amounts[i]
+		}
+		tries = append(tries, amounts)
+	case prompt.DeclareCode:
+		return nil, fmt.Errorf("%s: an automatic player cannot declare a card", p.Title)
+	}
+
+	err := fmt.Errorf("%s: nothing to choose", p.Title)
+	for _, sel := range tries {
+		var ans *duelpb.Answer
+		if ans, err = p.Answer(sel); err == nil {
+			return ans, nil
+		}
+	}
+	return nil, err
+}
+
+// first returns the indexes 0 to n-1.
+func first(n int) []int {
+	sel := make([]int, n)
+	for i := range sel {
+		sel[i] = i
+	}
+	return sel
+}
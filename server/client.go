@@ -0,0 +1,127 @@
+package server
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/spb8026/ygo-visualizer/fog"
+	"github.com/spb8026/ygo-visualizer/narrate"
+	"github.com/spb8026/ygo-visualizer/view"
+)
+
+// writeWait bounds how long a browser may take to accept one message.
+const writeWait = 10 * time.Second
+
+// client is one browser connection.
+type client struct {
+	s    *Server
+	conn *websocket.Conn
+	seat fog.Viewer
+	// out queues encoded messages for write; a browser that falls 256
+	// messages behind is dropped.
+	out chan []byte
+	// known holds the cards whose text the browser has. Guarded by s.mu.
+	known map[uint32]bool
+}
+
+// send queues v. s.mu must be held.
+func (c *client) send(v any) {
+	if !c.s.clients[c] {
+		return
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	select {
+	case c.out <- b:
+	default:
+		c.s.drop(c)
+	}
+}
+
+// sendAsking tells c about a, with the prompt if c is in the replier's
+// seat. s.mu must be held.
+func (c *client) sendAsking(a *asking, cards view.Cards) {
+	u := asked{Type: "prompt", ID: a.id, Player: a.player}
+	if c.seat == fog.Viewer(a.player) {
+		u.Prompt = a.prompt
+		var codes []uint32
+		for _, o := range a.prompt.Options {
+			codes = append(codes, o.Code)
+		}
+		u.Cards = c.unknown(cards, codes)
+	}
+	c.send(u)
+}
+
+// unknown returns the entries of cards for codes c has not been sent, and
+// marks them sent. s.mu must be held.
+func (c *client) unknown(cards view.Cards, codes []uint32) view.Cards {
+	var out view.Cards
+	for _, code := range codes {
+		info := cards[code]
+		if info == nil || c.known[code] {
+			continue
+		}
+		if out == nil {
+			out = make(view.Cards)
+		}
+		out[code] = info
+		c.known[code] = true
+	}
+	return out
+}
+
+func (c *client) write() {
+	defer c.conn.Close()
+	for b := range c.out {
+		c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+		if err := c.conn.WriteMessage(websocket.TextMessage, b); err != nil {
+			return
+		}
+	}
+	c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+}
+
+// read passes the browser's answers to Respond until the connection
+// closes. Answers from other seats, or to a prompt no longer open, are
+// rejected here.
+func (c *client) read() {
+	s := c.s
+	defer func() {
+		s.mu.Lock()
+		s.drop(c)
+		s.mu.Unlock()
+	}()
+	for {
+		var a answer
+		if err := c.conn.ReadJSON(&a); err != nil {
+			return
+		}
+		if a.Type != "answer" {
+			continue
+		}
+		s.mu.Lock()
+		var problem string
+		switch {
+		case s.asking == nil || s.asking.id != a.ID:
+			problem = "that choice is no longer open"
+		case c.seat != fog.Viewer(s.asking.player):
+			problem = "only " + narrate.PlayerName(s.asking.player) + " can answer"
+		}
+		if problem != "" {
+			c.send(rejected{Type: "rejected", ID: a.ID, Message: problem})
+			s.mu.Unlock()
+			continue
+		}
+		s.mu.Unlock()
+		select {
+		case s.answers <- reply{from: c, msg: a}:
+		case <-s.done:
+			return
+		}
+	}
+}
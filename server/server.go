@@ -0,0 +1,387 @@
+// Package server streams a duel to browsers. Every browser watches from a
+// seat, player 0, player 1 or the spectators, and gets the events, the
+// narration and board snapshots fog lets that seat see over a WebSocket.
+// Browsers in a player's seat answer that player's requests; any number
+// of browsers can share a seat. The board UI is built in.
+//
+// Server does not run the duel itself: whoever does passes it every
+// message with Feed and asks it for answers with Respond, as with the
+// terminal UI.
+package server
+
+import (
+	"crypto/subtle"
+	"embed"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/spb8026/ygo-visualizer/carddb"
+	"github.com/spb8026/ygo-visualizer/fog"
+	"github.com/spb8026/ygo-visualizer/narrate"
+	"github.com/spb8026/ygo-visualizer/prompt"
+	"github.com/spb8026/ygo-visualizer/render"
+	"github.com/spb8026/ygo-visualizer/view"
+	duelpb "github.com/spb8026/ygo-visualizer/ygopenpb"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+//go:embed ui
+var uiFiles embed.FS
+
+// ErrClosed is returned by Respond once the server is closed.
+var ErrClosed = errors.New("server closed")
+
+// logLines is how much narration per seat a browser gets on joining.
+const logLines = 1000
+
+type Options struct {
+	DB        carddb.Source
+	Lang      string
+	Verbosity narrate.Verbosity
+	// Auto marks the players nobody answers for: they decline whatever
+	// they can and otherwise take the first choice offered.
+	Auto [2]bool
+	// Tokens are the secrets a browser must pass as ?token= to take each
+	// player's seat, which shows that player's hand and answers for them.
+	// An empty token leaves the seat open. Spectators need none.
+	Tokens [2]string
+}
+
+// seats are the places a browser can watch from.
+var seats = [...]fog.Viewer{fog.Spectator, fog.Player0, fog.Player1}
+
+type Server struct {
+	opts     Options
+	audience *narrate.Audience
+	upgrader websocket.Upgrader
+
+	mu      sync.Mutex
+	clients map[*client]bool
+	logs    map[fog.Viewer][]string
+	boards  map[fog.Viewer]*view.Snapshot
+	cards   view.Cards
+	asking  *asking
+	lastID  int
+	ended   string
+
+	answers   chan reply
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// asking is the request waiting for an answer from a browser.
+type asking struct {
+	id     int
+	player int
+	prompt *prompt.Prompt
+}
+
+func New(opts Options) (*Server, error) {
+	s := &Server{
+		opts:     opts,
+		audience: narrate.NewAudience(),
+		clients:  make(map[*client]bool),
+		logs:     make(map[fog.Viewer][]string),
+		boards:   make(map[fog.Viewer]*view.Snapshot),
+		cards:    make(view.Cards),
+		answers:  make(chan reply, 16),
+		done:     make(chan struct{}),
+	}
+	for _, v := range seats {
+		if err := s.audience.Add(v, opts.DB, opts.Lang, opts.Verbosity); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Handler serves the board UI at / and its WebSocket at /ws?seat=0, 1 or
+// spectator, with &token= for a player's seat.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	ui, err := fs.Sub(uiFiles, "ui")
+	if err != nil {
+		panic(err)
+	}
+	mux.Handle("GET /", http.FileServerFS(ui))
+	mux.HandleFunc("GET /view/board.js", asset(view.Script, "text/javascript; charset=utf-8"))
+	mux.HandleFunc("GET /view/board.css", asset(view.Style, "text/css; charset=utf-8"))
+	mux.HandleFunc("GET /ws", s.serveWS)
+	return mux
+}
+
+func asset(body, contentType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.Write([]byte(body))
+	}
+}
+
+// Feed passes one message of the duel to every seat.
+func (s *Server) Feed(m *duelpb.Msg) error {
+	told, err := s.audience.Tell(m)
+	kind := render.EventKind(m)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, v := range seats {
+		u := event{Type: "event", Kind: kind}
+		t, seen := told[v]
+		switch {
+		case err != nil:
+			u.Log = []string{"board error: " + err.Error()}
+		case seen:
+			u.Log = t.Lines
+			b, merr := protojson.Marshal(t.Msg)
+			if merr != nil {
+				return merr
+			}
+			u.Event = b
+		}
+		if kind != "" && (seen || err != nil) {
+			u.Board = view.Snap(s.audience.Board(v), s.opts.DB, s.audience.Catalog(v))
+			s.boards[v] = u.Board
+			s.cards.AddSnapshot(u.Board, s.opts.DB)
+		}
+		if u.Event == nil && u.Board == nil && len(u.Log) == 0 {
+			continue
+		}
+		s.logs[v] = append(s.logs[v], u.Log...)
+		if n := len(s.logs[v]); n > logLines {
+			s.logs[v] = s.logs[v][n-logLines:]
+		}
+		for c := range s.clients {
+			if c.seat == v {
+				u.Cards = c.unknown(s.cards, u.Board.Codes())
+				c.send(u)
+			}
+		}
+	}
+	return nil
+}
+
+// Respond blocks until a browser in the replier's seat answers req, or
+// answers it at once for an Auto player. It returns ErrClosed once the
+// server is closed.
+func (s *Server) Respond(req *duelpb.Msg_Request) (*duelpb.Answer, error) {
+	select {
+	case <-s.done:
+		return nil, ErrClosed
+	default:
+	}
+	player := int(req.GetReplier() & 1)
+	v := fog.Viewer(player)
+	p, err := prompt.For(req, s.audience.Board(v), s.opts.DB, s.audience.Catalog(v))
+	if err != nil {
+		return nil, err
+	}
+	if s.opts.Auto[player] {
+		return pass(req, p)
+	}
+
+	s.mu.Lock()
+	s.lastID++
+	a := &asking{id: s.lastID, player: player, prompt: p}
+	s.asking = a
+	for _, o := range p.Options {
+		s.cards.Add(o.Code, s.opts.DB)
+	}
+	for c := range s.clients {
+		c.sendAsking(a, s.cards)
+	}
+	s.mu.Unlock()
+
+	for {
+		select {
+		case r := <-s.answers:
+			if r.msg.ID != a.id {
+				continue
+			}
+			ans, err := r.msg.build(p)
+			s.mu.Lock()
+			if err != nil {
+				r.from.send(rejected{Type: "rejected", ID: a.id, Message: err.Error()})
+				s.mu.Unlock()
+				continue
+			}
+			s.asking = nil
+			for c := range s.clients {
+				c.send(answered{Type: "answered", ID: a.id})
+			}
+			s.mu.Unlock()
+			return ans, nil
+		case <-s.done:
+			return nil, ErrClosed
+		}
+	}
+}
+
+// End tells every browser, now and later, that the duel is over.
+func (s *Server) End(msg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ended = msg
+	for c := range s.clients {
+		c.send(ended{Type: "end", Message: msg})
+	}
+}
+
+// Close disconnects every browser and makes Respond return ErrClosed.
+func (s *Server) Close() error {
+	s.closeOnce.Do(func() { close(s.done) })
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.clients {
+		s.drop(c)
+	}
+	return nil
+}
+
+// serveWS seats a browser and catches it up on the duel so far.
+func (s *Server) serveWS(w http.ResponseWriter, r *http.Request) {
+	var seat fog.Viewer
+	switch r.URL.Query().Get("seat") {
+	case "0":
+		seat = fog.Player0
+	case "1":
+		seat = fog.Player1
+	case "", "spectator":
+		seat = fog.Spectator
+	default:
+		http.Error(w, "seat must be 0, 1 or spectator", http.StatusBadRequest)
+		return
+	}
+	if seat != fog.Spectator {
+		want := s.opts.Tokens[seat]
+		got := r.URL.Query().Get("token")
+		if want != "" && subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+			http.Error(w, "wrong or missing token for this seat", http.StatusForbidden)
+			return
+		}
+	}
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already answered the request.
+		return
+	}
+	c := &client{s: s, conn: conn, seat: seat, out: make(chan []byte, 256), known: make(map[uint32]bool)}
+
+	s.mu.Lock()
+	select {
+	case <-s.done:
+		s.mu.Unlock()
+		conn.Close()
+		return
+	default:
+	}
+	s.clients[c] = true
+	c.send(hello{Type: "hello", Seat: int(seat), Auto: s.opts.Auto})
+	st := state{Type: "state", Board: s.boards[seat], Log: s.logs[seat]}
+	st.Cards = c.unknown(s.cards, st.Board.Codes())
+	c.send(st)
+	if s.asking != nil {
+		c.sendAsking(s.asking, s.cards)
+	}
+	if s.ended != "" {
+		c.send(ended{Type: "end", Message: s.ended})
+	}
+	s.mu.Unlock()
+
+	go c.write()
+	c.read()
+}
+
+// drop forgets c and closes its connection. s.mu must be held.
+func (s *Server) drop(c *client) {
+	if s.clients[c] {
+		delete(s.clients, c)
+		close(c.out)
+	}
+}
+
+/*
+   Browser protocol
+*/
+
+// Everything sent to a browser is a JSON object whose type field is one of
+// hello, state, event, prompt, answered, rejected or end. Cards carries
+// the text of cards the browser has not been sent yet.
+
+type hello struct {
+	Type string  `json:"type"`
+	Seat int     `json:"seat"`
+	Auto [2]bool `json:"auto"`
+}
+
+// state catches a browser up: the board and narration so far.
+type state struct {
+	Type  string         `json:"type"`
+	Board *view.Snapshot `json:"board,omitempty"`
+	Log   []string       `json:"log"`
+	Cards view.Cards     `json:"cards,omitempty"`
+}
+
+// event is one message as a seat sees it. Board is set for events.
+type event struct {
+	Type  string          `json:"type"`
+	Kind  string          `json:"kind,omitempty"`
+	Event json.RawMessage `json:"event,omitempty"`
+	Log   []string        `json:"log,omitempty"`
+	Board *view.Snapshot  `json:"board,omitempty"`
+	Cards view.Cards      `json:"cards,omitempty"`
+}
+
+// asked tells every browser who has to answer; browsers in that seat also
+// get the prompt.
+type asked struct {
+	Type   string         `json:"type"`
+	ID     int            `json:"id"`
+	Player int            `json:"player"`
+	Prompt *prompt.Prompt `json:"prompt,omitempty"`
+	Cards  view.Cards     `json:"cards,omitempty"`
+}
+
+type answered struct {
+	Type string `json:"type"`
+	ID   int    `json:"id"`
+}
+
+type rejected struct {
+	Type    string `json:"type"`
+	ID      int    `json:"id"`
+	Message string `json:"message"`
+}
+
+type ended struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// answer is what a browser sends: the selection for prompt ID, a card code
+// for a declaration, or a cancel.
+type answer struct {
+	Type   string `json:"type"`
+	ID     int    `json:"id"`
+	Select []int  `json:"select"`
+	Code   uint32 `json:"code"`
+	Cancel bool   `json:"cancel"`
+}
+
+func (a answer) build(p *prompt.Prompt) (*duelpb.Answer, error) {
+	switch {
+	case a.Cancel:
+		return p.Cancel()
+	case p.Mode == prompt.DeclareCode:
+		return p.Declare(a.Code)
+	}
+	return p.Answer(a.Select)
+}
+
+type reply struct {
+	from *client
+	msg  answer
+}
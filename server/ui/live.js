@@ -0,0 +1,243 @@
+// live.js follows a duel over the server's WebSocket, drawing boards with
+// YGOView and answering prompts for the seat the page watches from.
+"use strict";
+
+(function () {
+  // LOG_LINES is how much narration the log pane keeps.
+  var LOG_LINES = 1000;
+
+  var $ = function (id) { return document.getElementById(id); };
+  var board = $("board"), log = $("log"), event = $("event"), status = $("status");
+  var box = $("prompt"), options = $("prompt-options");
+  var submit = $("prompt-submit"), reset = $("prompt-reset"), cancel = $("prompt-cancel");
+
+  var params = new URLSearchParams(location.search);
+  var seatName = params.get("seat") || "spectator";
+  var token = params.get("token") || "";
+  var seat = seatName === "0" ? 0 : seatName === "1" ? 1 : -1;
+  var cards = {}, snap = null, ended = "", waiting = "";
+  var socket = null, open = null;
+
+  document.querySelectorAll("#seats a").forEach(function (a) {
+    if (Number(a.dataset.seat) === seat) {
+      a.classList.add("active");
+      a.href = location.search;
+    }
+  });
+  YGOView.tooltips($("main"), function () { return cards; });
+
+  function el(tag, cls, text) {
+    var e = document.createElement(tag);
+    if (cls) e.className = cls;
+    if (text != null) e.textContent = text;
+    return e;
+  }
+
+  function showStatus() {
+    var who = seat < 0 ? "Spectator" : YGOView.playerName(seat);
+    status.textContent = who + (ended ? " · " + ended : waiting ? " · " + waiting : "");
+  }
+
+  function draw() {
+    board.textContent = "";
+    if (snap) YGOView.draw(board, snap, cards, seat === 1 ? 1 : 0);
+  }
+
+  function addLog(lines) {
+    var old = log.querySelectorAll(".latest");
+    old.forEach(function (d) { d.classList.remove("latest"); });
+    lines.forEach(function (text) {
+      log.appendChild(el("div", "latest", text));
+    });
+    while (log.childNodes.length > LOG_LINES) log.removeChild(log.firstChild);
+    log.scrollTop = log.scrollHeight;
+  }
+
+  function send(v) {
+    if (socket && socket.readyState === WebSocket.OPEN) socket.send(JSON.stringify(v));
+  }
+
+  /*
+     Prompts
+  */
+
+  // ask shows the form for prompt p; every mode keeps its selection in
+  // picked and sends it with Confirm.
+  function ask(id, p) {
+    var picked = [];
+    open = id;
+    box.hidden = false;
+    $("prompt-title").textContent = p.title;
+    $("prompt-question").textContent = p.question;
+    $("prompt-error").textContent = "";
+    $("prompt-status").textContent = "";
+    options.textContent = "";
+    submit.hidden = p.mode === "one";
+    reset.hidden = p.mode !== "order";
+    cancel.hidden = !p.canCancel;
+
+    var answer = function (sel) { send({ type: "answer", id: id, select: sel }); };
+    var rows = (p.options || []).map(function (o, i) {
+      var row;
+      switch (p.mode) {
+        case "one":
+          row = el("button", null, o.label);
+          row.addEventListener("click", function () { answer([i]); });
+          break;
+        case "many":
+          row = el("label");
+          var check = el("input");
+          check.type = "checkbox";
+          check.addEventListener("change", function () {
+            row.classList.toggle("picked", check.checked);
+          });
+          row.appendChild(check);
+          row.appendChild(document.createTextNode(" " + o.label));
+          row.check = check;
+          break;
+        case "order":
+          row = el("button", null, o.label);
+          row.addEventListener("click", function () {
+            if (picked.indexOf(i) >= 0) return;
+            picked.push(i);
+            row.classList.add("picked");
+            row.textContent = picked.length + ". " + o.label;
+          });
+          break;
+        case "amounts":
+          row = el("label");
+          var n = el("input");
+          n.type = "number";
+          n.min = 0;
+          n.max = o.limit || 0;
+          n.value = 0;
+          row.appendChild(n);
+          row.appendChild(document.createTextNode(o.label + " (up to " + (o.limit || 0) + ")"));
+          row.amount = n;
+          break;
+      }
+      if (o.code) row.dataset.code = o.code;
+      options.appendChild(row);
+      return row;
+    });
+
+    var code = null;
+    if (p.mode === "code") {
+      code = el("input");
+      code.type = "number";
+      code.min = 0;
+      code.placeholder = "Card code";
+      options.appendChild(code);
+    }
+    if (p.mode === "many") {
+      $("prompt-status").textContent = p.min === p.max ?
+        "Pick " + p.max + "." : "Pick " + (p.min || 0) + " to " + p.max + ".";
+    }
+    if (p.mode === "amounts") $("prompt-status").textContent = "Total " + p.max + ".";
+
+    submit.onclick = function () {
+      switch (p.mode) {
+        case "many":
+          var sel = [];
+          rows.forEach(function (r, i) { if (r.check.checked) sel.push(i); });
+          answer(sel);
+          break;
+        case "order":
+          answer(picked);
+          break;
+        case "amounts":
+          answer(rows.map(function (r) { return Number(r.amount.value) || 0; }));
+          break;
+        case "code":
+          send({ type: "answer", id: id, code: Number(code.value) || 0 });
+          break;
+      }
+    };
+    reset.onclick = function () {
+      picked = [];
+      rows.forEach(function (r, i) {
+        r.classList.remove("picked");
+        r.textContent = p.options[i].label;
+      });
+    };
+    cancel.onclick = function () { send({ type: "answer", id: id, cancel: true }); };
+  }
+
+  function closePrompt() {
+    open = null;
+    box.hidden = true;
+  }
+
+  /*
+     Connection
+  */
+
+  function handle(u) {
+    if (u.cards) Object.assign(cards, u.cards);
+    switch (u.type) {
+      case "hello":
+        seat = u.seat;
+        break;
+      case "state":
+        snap = u.board || null;
+        log.textContent = "";
+        addLog(u.log || []);
+        draw();
+        break;
+      case "event":
+        if (u.log) addLog(u.log);
+        if (u.event) event.textContent = JSON.stringify(u.event, null, 2);
+        if (u.board) {
+          snap = u.board;
+          draw();
+        }
+        break;
+      case "prompt":
+        if (u.prompt) {
+          waiting = "";
+          ask(u.id, u.prompt);
+        } else {
+          closePrompt();
+          waiting = "Waiting for " + YGOView.playerName(u.player);
+        }
+        break;
+      case "answered":
+        waiting = "";
+        if (open === u.id) closePrompt();
+        break;
+      case "rejected":
+        if (open === u.id) $("prompt-error").textContent = u.message;
+        break;
+      case "end":
+        ended = u.message;
+        closePrompt();
+        break;
+    }
+    showStatus();
+  }
+
+  // connect joins the seat and rejoins after a disconnect. A seat that
+  // refuses the first connection, usually for a wrong token, is not
+  // retried.
+  function connect(first) {
+    var scheme = location.protocol === "https:" ? "wss://" : "ws://";
+    var url = scheme + location.host + "/ws?seat=" + encodeURIComponent(seatName);
+    if (token) url += "&token=" + encodeURIComponent(token);
+    var opened = false;
+    socket = new WebSocket(url);
+    socket.onopen = function () { opened = true; };
+    socket.onmessage = function (ev) { handle(JSON.parse(ev.data)); };
+    socket.onclose = function () {
+      closePrompt();
+      if (ended) return;
+      if (first && !opened) {
+        status.textContent = "Could not join this seat. Players need the link the server printed.";
+        return;
+      }
+      status.textContent = "Disconnected, retrying…";
+      setTimeout(function () { connect(false); }, 2000);
+    };
+  }
+
+  connect(true);
+})();
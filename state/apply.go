@@ -0,0 +1,612 @@
+package state
+
+import (
+	"fmt"
+	"sort"
+
+	duelpb "github.com/spb8026/ygo-visualizer/ygopenpb"
+	"google.golang.org/protobuf/proto"
+)
+
+// Apply updates the board with one message: its event first, then the
+// queries that describe the cards after the event. The invariants are
+// checked afterwards. Requests leave the board unchanged.
+func (b *Board) Apply(m *duelpb.Msg) error {
+	if ev := m.GetEvent(); ev != nil {
+		if err := b.ApplyEvent(ev); err != nil {
+			return fmt.Errorf("event %d: %w", b.Events, err)
+		}
+	}
+	if err := b.ApplyQueries(m.GetQueries()); err != nil {
+		return fmt.Errorf("event %d: %w", b.Events, err)
+	}
+	if err := b.Check(); err != nil {
+		return fmt.Errorf("event %d broke board invariant: %w", b.Events, err)
+	}
+	return nil
+}
+
+// ApplyEvent updates the board with a single event. Events that change
+// neither the board nor the chain (results, zone blocks, most meta events)
+// are accepted and ignored.
+func (b *Board) ApplyEvent(ev *duelpb.Msg_Event) error {
+	b.Events++
+
+	switch t := ev.GetT().(type) {
+	case *duelpb.Msg_Event_Board_:
+		return b.applyBoard(t.Board)
+	case *duelpb.Msg_Event_Card_:
+		return b.applyCard(t.Card)
+	case *duelpb.Msg_Event_Pile_:
+		return b.applyPile(t.Pile)
+	case *duelpb.Msg_Event_Lp:
+		return b.applyLP(t.Lp)
+	case *duelpb.Msg_Event_NextTurn:
+		if t.NextTurn != 0 && t.NextTurn != 1 {
+			return fmt.Errorf("next turn for invalid player %d", t.NextTurn)
+		}
+		b.Turn++
+		b.TurnPlayer = int(t.NextTurn)
+	case *duelpb.Msg_Event_NextPhase:
+		b.Phase = t.NextPhase
+	case *duelpb.Msg_Event_ChainStack_:
+		return b.applyChainStack(t.ChainStack)
+	case *duelpb.Msg_Event_Meta_:
+		b.applyChainMeta(t.Meta)
+		if sp := t.Meta.GetShufflePile(); sp != nil {
+			for _, place := range sp.GetPlaces() {
+				pile, err := b.pile(place)
+				if err != nil {
+					return fmt.Errorf("shuffle pile: %w", err)
+				}
+				for _, c := range *pile {
+					c.Code, c.Alias, c.Public = 0, 0, false
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ApplyQueries copies queried card data onto the cards at each place.
+func (b *Board) ApplyQueries(queries []*duelpb.Msg_Query) error {
+	for _, q := range queries {
+		c, err := b.lookup(q.GetPlace())
+		if err != nil {
+			return fmt.Errorf("query: %w", err)
+		}
+		if c == nil {
+			return fmt.Errorf("query for empty place %s", PlaceString(q.GetPlace()))
+		}
+		b.applyChainQuery(q)
+
+		d := q.GetData()
+		if d.GetCode() != nil {
+			c.Code = d.GetCode().GetValue()
+		}
+		if d.GetAlias() != nil {
+			c.Alias = d.GetAlias().GetValue()
+		}
+		if d.GetPosition() != nil {
+			c.Position = d.GetPosition().GetValue()
+		}
+		if d.GetOwner() != nil {
+			c.Owner = int(d.GetOwner().GetValue())
+		}
+		if d.GetLevel() != nil {
+			c.Level = d.GetLevel().GetValue()
+		}
+		if d.GetAtk() != nil {
+			c.Atk = d.GetAtk().GetValue()
+		}
+		if d.GetDef() != nil {
+			c.Def = d.GetDef().GetValue()
+		}
+		if d.GetIsPublic() != nil {
+			c.Public = d.GetIsPublic().GetValue()
+		}
+		if d.GetCounters() != nil {
+			c.Counters = nil
+			for _, ctr := range d.GetCounters().GetValues() {
+				c.Counters = append(c.Counters, Counter{Type: ctr.GetType(), Count: ctr.GetCount()})
+			}
+		}
+	}
+	return nil
+}
+
+/*
+   Event handlers
+*/
+
+func (b *Board) applyBoard(ev *duelpb.Msg_Event_Board) error {
+	switch t := ev.GetT().(type) {
+	case *duelpb.Msg_Event_Board_State_:
+		st := t.State
+		if st.GetTurnCounter() >= 0 {
+			b.Turn = int(st.GetTurnCounter())
+		}
+		for con, lp := range st.GetLps() {
+			if con < len(b.Players) {
+				b.Players[con].LP = lp
+			}
+		}
+		if shape := st.GetShape(); shape != nil {
+			b.SeparatePZones = shape.GetHasSeparatePzones()
+			b.Shape = proto.Clone(shape).(*duelpb.FieldShape)
+		}
+		for _, ch := range st.GetChains() {
+			b.pushChain(ch)
+		}
+		if err := b.addCards(st.GetAdd().GetPlaces()); err != nil {
+			return fmt.Errorf("board state: %w", err)
+		}
+		if err := b.resize(st.GetResize().GetOps()); err != nil {
+			return fmt.Errorf("board state: %w", err)
+		}
+	case *duelpb.Msg_Event_Board_Exchange_:
+		ex := t.Exchange
+		if err := b.removeCards(ex.GetRemove().GetPlaces()); err != nil {
+			return fmt.Errorf("board exchange: %w", err)
+		}
+		if err := b.addCards(ex.GetAdd().GetPlaces()); err != nil {
+			return fmt.Errorf("board exchange: %w", err)
+		}
+		if err := b.resize(ex.GetResize().GetOps()); err != nil {
+			return fmt.Errorf("board exchange: %w", err)
+		}
+	}
+	return nil
+}
+
+func (b *Board) applyCard(ev *duelpb.Msg_Event_Card) error {
+	switch t := ev.GetT().(type) {
+	case *duelpb.Msg_Event_Card_Add_:
+		if err := b.addCards(t.Add.GetPlaces()); err != nil {
+			return fmt.Errorf("card add: %w", err)
+		}
+	case *duelpb.Msg_Event_Card_Remove_:
+		if err := b.removeCards(t.Remove.GetPlaces()); err != nil {
+			return fmt.Errorf("card remove: %w", err)
+		}
+	case *duelpb.Msg_Event_Card_Move_:
+		if err := b.move(t.Move.GetOps()); err != nil {
+			return fmt.Errorf("card move: %w", err)
+		}
+	case *duelpb.Msg_Event_Card_Exchange_:
+		for _, op := range t.Exchange.GetOps() {
+			if err := b.exchange(op.GetPlaceA(), op.GetPlaceB()); err != nil {
+				return fmt.Errorf("card exchange: %w", err)
+			}
+		}
+	case *duelpb.Msg_Event_Card_Shuffle_:
+		if err := b.shuffle(t.Shuffle); err != nil {
+			return fmt.Errorf("card shuffle: %w", err)
+		}
+	}
+	return nil
+}
+
+func (b *Board) applyPile(ev *duelpb.Msg_Event_Pile) error {
+	switch t := ev.GetT().(type) {
+	case *duelpb.Msg_Event_Pile_Resize_:
+		if err := b.resize(t.Resize.GetOps()); err != nil {
+			return fmt.Errorf("pile resize: %w", err)
+		}
+	case *duelpb.Msg_Event_Pile_Exchange_:
+		for _, op := range t.Exchange.GetOps() {
+			a, err := b.pile(op.GetPlaceA())
+			if err != nil {
+				return fmt.Errorf("pile exchange: %w", err)
+			}
+			c, err := b.pile(op.GetPlaceB())
+			if err != nil {
+				return fmt.Errorf("pile exchange: %w", err)
+			}
+			*a, *c = *c, *a
+		}
+	case *duelpb.Msg_Event_Pile_Splice_:
+		for _, op := range t.Splice.GetOps() {
+			if err := b.splice(op); err != nil {
+				return fmt.Errorf("pile splice: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+func (b *Board) applyLP(ev *duelpb.Msg_Event_LP) error {
+	con := ev.GetController()
+	if con != 0 && con != 1 {
+		return fmt.Errorf("lp change for invalid player %d", con)
+	}
+	p := b.Players[con]
+
+	switch t := ev.GetT().(type) {
+	case *duelpb.Msg_Event_LP_Become:
+		p.LP = t.Become
+	case *duelpb.Msg_Event_LP_Damage:
+		p.LP = subLP(p.LP, t.Damage)
+	case *duelpb.Msg_Event_LP_Pay:
+		p.LP = subLP(p.LP, t.Pay)
+	case *duelpb.Msg_Event_LP_Recover:
+		p.LP += t.Recover
+	}
+	return nil
+}
+
+func subLP(lp, n uint32) uint32 {
+	if n >= lp {
+		return 0
+	}
+	return lp - n
+}
+
+/*
+   Board primitives
+*/
+
+func (b *Board) player(place *duelpb.Place) (*Player, error) {
+	if place == nil {
+		return nil, fmt.Errorf("missing place")
+	}
+	con := place.GetCon()
+	if con != 0 && con != 1 {
+		return nil, fmt.Errorf("invalid controller in %s", PlaceString(place))
+	}
+	return b.Players[con], nil
+}
+
+// slot returns a pointer to the board slot at place. For piles the slot
+// must already exist.
+func (b *Board) slot(place *duelpb.Place) (**Card, error) {
+	p, err := b.player(place)
+	if err != nil {
+		return nil, err
+	}
+	loc := place.GetLoc() &^ LOC_OVERLAY
+	seq := int(place.GetSeq())
+
+	if place.GetOseq() >= 0 {
+		if loc != LOC_MZONE || seq >= MonsterZones {
+			return nil, fmt.Errorf("overlay place %s is not under a monster zone", PlaceString(place))
+		}
+		o := int(place.GetOseq())
+		if o >= len(p.Overlays[seq]) {
+			return nil, fmt.Errorf("no overlay unit at %s (%d attached)", PlaceString(place), len(p.Overlays[seq]))
+		}
+		return &p.Overlays[seq][o], nil
+	}
+
+	switch loc {
+	case LOC_MZONE:
+		if seq >= MonsterZones {
+			return nil, fmt.Errorf("invalid monster zone %s", PlaceString(place))
+		}
+		return &p.Monsters[seq], nil
+	case LOC_SZONE:
+		if seq >= SpellZones {
+			return nil, fmt.Errorf("invalid spell zone %s", PlaceString(place))
+		}
+		return &p.Spells[seq], nil
+	}
+
+	pile := p.Pile(loc)
+	if pile == nil {
+		return nil, fmt.Errorf("unknown location in %s", PlaceString(place))
+	}
+	if seq >= len(*pile) {
+		return nil, fmt.Errorf("no card at %s (pile has %d)", PlaceString(place), len(*pile))
+	}
+	return &(*pile)[seq], nil
+}
+
+func (b *Board) lookup(place *duelpb.Place) (*Card, error) {
+	s, err := b.slot(place)
+	if err != nil {
+		return nil, err
+	}
+	return *s, nil
+}
+
+func (b *Board) pile(place *duelpb.Place) (*[]*Card, error) {
+	p, err := b.player(place)
+	if err != nil {
+		return nil, err
+	}
+	pile := p.Pile(place.GetLoc())
+	if pile == nil {
+		return nil, fmt.Errorf("%s is not a pile", PlaceString(place))
+	}
+	return pile, nil
+}
+
+// insert puts c at place: into a pile or overlay list at the given index,
+// or into an empty zone.
+func (b *Board) insert(place *duelpb.Place, c *Card) error {
+	p, err := b.player(place)
+	if err != nil {
+		return err
+	}
+	loc := place.GetLoc() &^ LOC_OVERLAY
+	seq := int(place.GetSeq())
+
+	if place.GetOseq() >= 0 {
+		if loc != LOC_MZONE || seq >= MonsterZones {
+			return fmt.Errorf("overlay place %s is not under a monster zone", PlaceString(place))
+		}
+		p.Overlays[seq] = insertAt(p.Overlays[seq], int(place.GetOseq()), c)
+		return nil
+	}
+
+	if loc == LOC_MZONE || loc == LOC_SZONE {
+		s, err := b.slot(place)
+		if err != nil {
+			return err
+		}
+		if *s != nil {
+			return fmt.Errorf("%s is already occupied", PlaceString(place))
+		}
+		*s = c
+		return nil
+	}
+
+	pile := p.Pile(loc)
+	if pile == nil {
+		return fmt.Errorf("unknown location in %s", PlaceString(place))
+	}
+	*pile = insertAt(*pile, seq, c)
+	return nil
+}
+
+func insertAt(pile []*Card, i int, c *Card) []*Card {
+	if i > len(pile) {
+		i = len(pile)
+	}
+	pile = append(pile, nil)
+	copy(pile[i+1:], pile[i:])
+	pile[i] = c
+	return pile
+}
+
+// detach removes c from wherever it is on the board.
+func (b *Board) detach(c *Card) bool {
+	for _, p := range b.Players {
+		for _, loc := range []uint32{LOC_DECK, LOC_HAND, LOC_EXTRA, LOC_GRAVE, LOC_REMOVED} {
+			pile := p.Pile(loc)
+			if removeFrom(pile, c) {
+				return true
+			}
+		}
+		for seq := range p.Monsters {
+			if p.Monsters[seq] == c {
+				p.Monsters[seq] = nil
+				return true
+			}
+			if removeFrom(&p.Overlays[seq], c) {
+				return true
+			}
+		}
+		for seq := range p.Spells {
+			if p.Spells[seq] == c {
+				p.Spells[seq] = nil
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func removeFrom(pile *[]*Card, c *Card) bool {
+	for i, x := range *pile {
+		if x == c {
+			*pile = append((*pile)[:i], (*pile)[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// collect resolves every place to its card before anything is moved, so
+// removing one card does not shift the index of the next.
+func (b *Board) collect(places []*duelpb.Place) ([]*Card, error) {
+	cards := make([]*Card, len(places))
+	for i, place := range places {
+		c, err := b.lookup(place)
+		if err != nil {
+			return nil, err
+		}
+		if c == nil {
+			return nil, fmt.Errorf("no card at %s", PlaceString(place))
+		}
+		cards[i] = c
+	}
+	return cards, nil
+}
+
+// placeOrder sorts indexes of places so that inserting in that order leaves
+// every card at its requested index.
+func placeOrder(places []*duelpb.Place) []int {
+	order := make([]int, len(places))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, c := places[order[i]], places[order[j]]
+		if a.GetSeq() != c.GetSeq() {
+			return a.GetSeq() < c.GetSeq()
+		}
+		return a.GetOseq() < c.GetOseq()
+	})
+	return order
+}
+
+func (b *Board) addCards(places []*duelpb.Place) error {
+	for _, i := range placeOrder(places) {
+		place := places[i]
+		c := &Card{Owner: int(place.GetCon())}
+		if place.GetLoc() == LOC_DECK || place.GetLoc() == LOC_EXTRA {
+			c.Position = POS_FACEDOWN_DEFENSE
+		}
+		if err := b.insert(place, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Board) removeCards(places []*duelpb.Place) error {
+	cards, err := b.collect(places)
+	if err != nil {
+		return err
+	}
+	for _, c := range cards {
+		b.detach(c)
+	}
+	return nil
+}
+
+func (b *Board) move(ops []*duelpb.Msg_Event_Card_Move_XOperation) error {
+	olds := make([]*duelpb.Place, len(ops))
+	news := make([]*duelpb.Place, len(ops))
+	for i, op := range ops {
+		olds[i], news[i] = op.GetOldPlace(), op.GetNewPlace()
+	}
+	cards, err := b.collect(olds)
+	if err != nil {
+		return err
+	}
+
+	// A monster moving between monster zones takes its materials along.
+	carried := make([][]*Card, len(ops))
+	for i, old := range olds {
+		nw := news[i]
+		if old.GetLoc() == LOC_MZONE && old.GetOseq() < 0 && nw.GetLoc() == LOC_MZONE && nw.GetOseq() < 0 &&
+			old.GetSeq() < MonsterZones {
+			p := b.Players[old.GetCon()]
+			carried[i] = p.Overlays[old.GetSeq()]
+			p.Overlays[old.GetSeq()] = nil
+		}
+	}
+
+	for _, c := range cards {
+		b.detach(c)
+	}
+	for _, i := range placeOrder(news) {
+		if err := b.insert(news[i], cards[i]); err != nil {
+			return err
+		}
+		// Counters are removed when a card leaves the field.
+		if loc := news[i].GetLoc(); (loc != LOC_MZONE && loc != LOC_SZONE) || news[i].GetOseq() >= 0 {
+			cards[i].Counters = nil
+		}
+		if carried[i] != nil {
+			p := b.Players[news[i].GetCon()]
+			p.Overlays[news[i].GetSeq()] = append(p.Overlays[news[i].GetSeq()], carried[i]...)
+		}
+	}
+	return nil
+}
+
+func (b *Board) exchange(a, c *duelpb.Place) error {
+	sa, err := b.slot(a)
+	if err != nil {
+		return err
+	}
+	sc, err := b.slot(c)
+	if err != nil {
+		return err
+	}
+	*sa, *sc = *sc, *sa
+
+	if a.GetLoc() == LOC_MZONE && c.GetLoc() == LOC_MZONE && a.GetOseq() < 0 && c.GetOseq() < 0 {
+		pa, pc := b.Players[a.GetCon()], b.Players[c.GetCon()]
+		pa.Overlays[a.GetSeq()], pc.Overlays[c.GetSeq()] = pc.Overlays[c.GetSeq()], pa.Overlays[a.GetSeq()]
+	}
+	return nil
+}
+
+// shuffle moves the cards at the previous places to the current places.
+// When the current places are unspecified the cards stay put and only
+// their identities are forgotten.
+func (b *Board) shuffle(ev *duelpb.Msg_Event_Card_Shuffle) error {
+	prev, cur := ev.GetPreviousPlaces(), ev.GetCurrentPlaces()
+	cards, err := b.collect(prev)
+	if err != nil {
+		return err
+	}
+
+	known := len(cur) == len(prev)
+	for _, place := range cur {
+		if place.GetLoc() == 0 {
+			known = false
+		}
+	}
+	if !known {
+		for _, c := range cards {
+			c.Code, c.Alias, c.Public = 0, 0, false
+		}
+		return nil
+	}
+
+	for _, c := range cards {
+		b.detach(c)
+	}
+	for _, i := range placeOrder(cur) {
+		if err := b.insert(cur[i], cards[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Board) resize(ops []*duelpb.Msg_Event_Pile_Resize_XOperation) error {
+	for _, op := range ops {
+		pile, err := b.pile(op.GetPlace())
+		if err != nil {
+			return err
+		}
+		n := int(op.GetCount())
+		for len(*pile) < n {
+			c := &Card{Owner: int(op.GetPlace().GetCon())}
+			if loc := op.GetPlace().GetLoc(); loc == LOC_DECK || loc == LOC_EXTRA {
+				c.Position = POS_FACEDOWN_DEFENSE
+			}
+			*pile = append(*pile, c)
+		}
+		*pile = (*pile)[:n]
+	}
+	return nil
+}
+
+func (b *Board) splice(op *duelpb.Msg_Event_Pile_Splice_XOperation) error {
+	from, err := b.pile(op.GetFrom())
+	if err != nil {
+		return err
+	}
+	to, err := b.pile(op.GetTo())
+	if err != nil {
+		return err
+	}
+
+	start, n := int(op.GetFrom().GetSeq()), int(op.GetCount())
+	if start+n > len(*from) {
+		return fmt.Errorf("cannot take %d cards from %s (pile has %d)", n, PlaceString(op.GetFrom()), len(*from))
+	}
+	moved := append([]*Card(nil), (*from)[start:start+n]...)
+	*from = append((*from)[:start], (*from)[start+n:]...)
+
+	if op.GetReverse() {
+		for i, j := 0, len(moved)-1; i < j; i, j = i+1, j-1 {
+			moved[i], moved[j] = moved[j], moved[i]
+		}
+	}
+
+	at := int(op.GetTo().GetSeq())
+	if at > len(*to) {
+		at = len(*to)
+	}
+	rest := append([]*Card(nil), (*to)[at:]...)
+	*to = append(append((*to)[:at], moved...), rest...)
+	return nil
+}
@@ -0,0 +1,318 @@
+// Package state keeps a Go-side model of the duel board, rebuilt by
+// applying the YGOpen event stream produced by bridge.Duel.Step.
+package state
+
+import (
+	"fmt"
+	"strings"
+
+	duelpb "github.com/spb8026/ygo-visualizer/ygopenpb"
+)
+
+// Location and position values as used by the core and in duelpb.Place.
+// They mirror bridge.LOC_* without importing the cgo bridge.
+const (
+	LOC_DECK    = 0x1
+	LOC_HAND    = 0x2
+	LOC_MZONE   = 0x4
+	LOC_SZONE   = 0x20
+	LOC_GRAVE   = 0x8
+	LOC_REMOVED = 0x10
+	LOC_EXTRA   = 0x40
+	LOC_OVERLAY = 0x80
+
+	POS_FACEUP_ATTACK    = 0x1
+	POS_FACEDOWN_ATTACK  = 0x2
+	POS_FACEUP_DEFENSE   = 0x4
+	POS_FACEDOWN_DEFENSE = 0x8
+	POS_FACEUP           = 0x5
+	POS_FACEDOWN         = 0xa
+)
+
+// Zone counts and fixed sequences within LOC_MZONE / LOC_SZONE.
+const (
+	MonsterZones = 7 // 0-4 main monster zones, 5-6 Extra Monster Zones
+	SpellZones   = 8 // 0-4 spell & trap zones, 5 field, 6-7 separate pendulum zones
+
+	SeqEMZLeft    = 5
+	SeqEMZRight   = 6
+	SeqFieldZone  = 5
+	SeqPZoneLeft  = 6
+	SeqPZoneRight = 7
+)
+
+// Card is one card on the board. Code is 0 while the card is unknown
+// (face-down, in a hidden pile, or not yet queried).
+type Card struct {
+	Code     uint32
+	Alias    uint32
+	Owner    int
+	Position uint32
+	Level    int32
+	Atk, Def int32
+	// Public reports a card in a hidden location that has been revealed.
+	Public bool
+	// Counters are the counters on the card as last queried, in the order
+	// the core reports them.
+	Counters []Counter
+}
+
+// Counter is a number of counters of one COUNTER_* type.
+type Counter struct {
+	Type, Count uint32
+}
+
+func (c *Card) FaceUp() bool {
+	return c.Position&POS_FACEUP != 0
+}
+
+func (c *Card) clone() *Card {
+	cp := *c
+	return &cp
+}
+
+// Player is one side of the board.
+type Player struct {
+	LP uint32
+
+	Deck     []*Card // index 0 is the bottom card
+	Hand     []*Card
+	Extra    []*Card
+	Grave    []*Card
+	Banished []*Card
+
+	Monsters [MonsterZones]*Card
+	Spells   [SpellZones]*Card
+	// Overlays holds the Xyz materials attached under each monster zone.
+	// They belong to the zone rather than the card because the core moves
+	// materials after their Xyz monster has already left the field.
+	Overlays [MonsterZones][]*Card
+}
+
+// EMZ returns the two Extra Monster Zones.
+func (p *Player) EMZ() []*Card {
+	return p.Monsters[SeqEMZLeft : SeqEMZRight+1]
+}
+
+// FieldZone returns the Field Spell, or nil.
+func (p *Player) FieldZone() *Card {
+	return p.Spells[SeqFieldZone]
+}
+
+// PendulumZones returns the left and right Pendulum Zones. With the
+// separate zones of older rules they are spell zones 6-7, otherwise the
+// outermost spell & trap zones.
+func (p *Player) PendulumZones(separate bool) (left, right *Card) {
+	if separate {
+		return p.Spells[SeqPZoneLeft], p.Spells[SeqPZoneRight]
+	}
+	return p.Spells[0], p.Spells[4]
+}
+
+// DeckCount is the number of cards left in the deck.
+func (p *Player) DeckCount() int {
+	return len(p.Deck)
+}
+
+// Pile returns a pointer to the pile slice for loc, or nil if loc is not a
+// pile location.
+func (p *Player) Pile(loc uint32) *[]*Card {
+	switch loc {
+	case LOC_DECK:
+		return &p.Deck
+	case LOC_HAND:
+		return &p.Hand
+	case LOC_EXTRA:
+		return &p.Extra
+	case LOC_GRAVE:
+		return &p.Grave
+	case LOC_REMOVED:
+		return &p.Banished
+	}
+	return nil
+}
+
+// Board is the whole duel state as seen through the event stream.
+type Board struct {
+	Players [2]*Player
+
+	Turn       int
+	TurnPlayer int
+	Phase      uint32
+	// SeparatePZones is set from the field shape of a Board.State event.
+	SeparatePZones bool
+	// Shape is the field shape of the last Board.State event, or nil for
+	// the current Master Rule field.
+	Shape *duelpb.FieldShape
+
+	// Chains tracks the open chain and completed chains.
+	Chains *Chains
+
+	// Events counts the events applied so far.
+	Events int
+}
+
+func New() *Board {
+	return &Board{Players: [2]*Player{{}, {}}, Chains: &Chains{}}
+}
+
+// HasEMZ reports whether the field has Extra Monster Zones.
+func (b *Board) HasEMZ() bool {
+	return b.Shape == nil || b.Shape.GetHasEmzones()
+}
+
+// HasPZones reports whether the field has Pendulum Zones, separate or not.
+func (b *Board) HasPZones() bool {
+	return b.Shape == nil || b.Shape.GetHasPzones()
+}
+
+// Clone returns a deep copy of b; cards are copied, not shared.
+func (b *Board) Clone() *Board {
+	cp := *b
+	for i, p := range b.Players {
+		np := *p
+		np.Deck = clonePile(p.Deck)
+		np.Hand = clonePile(p.Hand)
+		np.Extra = clonePile(p.Extra)
+		np.Grave = clonePile(p.Grave)
+		np.Banished = clonePile(p.Banished)
+		for s, c := range p.Monsters {
+			if c != nil {
+				np.Monsters[s] = c.clone()
+			}
+			np.Overlays[s] = clonePile(p.Overlays[s])
+		}
+		for s, c := range p.Spells {
+			if c != nil {
+				np.Spells[s] = c.clone()
+			}
+		}
+		cp.Players[i] = &np
+	}
+	cp.Chains = b.Chains.clone()
+	return &cp
+}
+
+func clonePile(pile []*Card) []*Card {
+	if pile == nil {
+		return nil
+	}
+	out := make([]*Card, len(pile))
+	for i, c := range pile {
+		out[i] = c.clone()
+	}
+	return out
+}
+
+// At returns the card at place, or nil if the place is empty or invalid.
+func (b *Board) At(place *duelpb.Place) *Card {
+	c, _ := b.lookup(place)
+	return c
+}
+
+// Check verifies the structural invariants of the board: every slot of a
+// pile holds a card, no card appears twice, and the turn player is valid.
+func (b *Board) Check() error {
+	if b.TurnPlayer != 0 && b.TurnPlayer != 1 {
+		return fmt.Errorf("invalid turn player %d", b.TurnPlayer)
+	}
+
+	seen := make(map[*Card]string)
+	visit := func(c *Card, where string) error {
+		if c == nil {
+			return fmt.Errorf("%s is an empty pile slot", where)
+		}
+		if prev, ok := seen[c]; ok {
+			return fmt.Errorf("card at %s is also at %s", where, prev)
+		}
+		seen[c] = where
+		return nil
+	}
+
+	for con, p := range b.Players {
+		for _, loc := range []uint32{LOC_DECK, LOC_HAND, LOC_EXTRA, LOC_GRAVE, LOC_REMOVED} {
+			for seq, c := range *p.Pile(loc) {
+				if err := visit(c, placeString(con, loc, seq, -1)); err != nil {
+					return err
+				}
+			}
+		}
+		for seq, c := range p.Monsters {
+			if c != nil {
+				if err := visit(c, placeString(con, LOC_MZONE, seq, -1)); err != nil {
+					return err
+				}
+			}
+			for o, m := range p.Overlays[seq] {
+				if err := visit(m, placeString(con, LOC_MZONE, seq, o)); err != nil {
+					return err
+				}
+			}
+		}
+		for seq, c := range p.Spells {
+			if c != nil {
+				if err := visit(c, placeString(con, LOC_SZONE, seq, -1)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// LocationName returns a short name for a location value.
+func LocationName(loc uint32) string {
+	switch loc {
+	case LOC_DECK:
+		return "deck"
+	case LOC_HAND:
+		return "hand"
+	case LOC_MZONE:
+		return "mzone"
+	case LOC_SZONE:
+		return "szone"
+	case LOC_GRAVE:
+		return "grave"
+	case LOC_REMOVED:
+		return "banished"
+	case LOC_EXTRA:
+		return "extra"
+	case LOC_OVERLAY:
+		return "overlay"
+	}
+	return fmt.Sprintf("loc(0x%x)", loc)
+}
+
+// PlaceString formats a place as "p0 mzone 2" or "p0 mzone 2 #1" for an
+// overlay unit.
+func PlaceString(place *duelpb.Place) string {
+	return placeString(int(place.GetCon()), place.GetLoc(), int(place.GetSeq()), int(place.GetOseq()))
+}
+
+func placeString(con int, loc uint32, seq, oseq int) string {
+	s := fmt.Sprintf("p%d %s %d", con, LocationName(loc), seq)
+	if oseq >= 0 {
+		s += fmt.Sprintf(" #%d", oseq)
+	}
+	return s
+}
+
+func (b *Board) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "turn %d (player %d), phase 0x%x\n", b.Turn, b.TurnPlayer, b.Phase)
+	for con, p := range b.Players {
+		fmt.Fprintf(&sb, "p%d LP %d  deck %d  hand %d  extra %d  grave %d  banished %d\n",
+			con, p.LP, len(p.Deck), len(p.Hand), len(p.Extra), len(p.Grave), len(p.Banished))
+		for seq, c := range p.Monsters {
+			if c != nil {
+				fmt.Fprintf(&sb, "  mzone %d: %d pos 0x%x (%d materials)\n", seq, c.Code, c.Position, len(p.Overlays[seq]))
+			}
+		}
+		for seq, c := range p.Spells {
+			if c != nil {
+				fmt.Fprintf(&sb, "  szone %d: %d pos 0x%x\n", seq, c.Code, c.Position)
+			}
+		}
+	}
+	return sb.String()
+}
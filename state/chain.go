@@ -0,0 +1,237 @@
+package state
+
+import (
+	"fmt"
+	"slices"
+
+	duelpb "github.com/spb8026/ygo-visualizer/ygopenpb"
+	"google.golang.org/protobuf/proto"
+)
+
+// Outcome is how a chain link ended.
+type Outcome int
+
+const (
+	OutcomePending Outcome = iota
+	OutcomeResolved
+	// OutcomeNegated: the activation was negated.
+	OutcomeNegated
+	// OutcomeDisabled: the activation stood but its effect was negated.
+	OutcomeDisabled
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case OutcomeResolved:
+		return "resolved"
+	case OutcomeNegated:
+		return "negated"
+	case OutcomeDisabled:
+		return "disabled"
+	default:
+		return "pending"
+	}
+}
+
+// Link is one chain link.
+type Link struct {
+	Number     int // 1-based position in its chain
+	Turn       int
+	Code       uint32
+	Controller int
+	// CardPlace is where the card was when it activated; Place is the
+	// triggering place reported by the core.
+	CardPlace *duelpb.Place
+	Place     *duelpb.Place
+	Effect    *duelpb.Effect
+	Targets   []*duelpb.Place
+	Outcome   Outcome
+	Solving   bool
+}
+
+// Description is the string id of the activated effect, as used by card
+// scripts (code*16 + index).
+func (l *Link) Description() uint64 {
+	return uint64(l.Effect.GetCode())<<4 | uint64(l.Effect.GetIndex())
+}
+
+func (l *Link) String() string {
+	s := fmt.Sprintf("CL%d %d by p%d", l.Number, l.Code, l.Controller)
+	if len(l.Targets) > 0 {
+		s += fmt.Sprintf(" targeting %d card(s)", len(l.Targets))
+	}
+	return s + ": " + l.Outcome.String()
+}
+
+func (l *Link) clone() *Link {
+	cp := *l
+	cp.Targets = append([]*duelpb.Place(nil), l.Targets...)
+	return &cp
+}
+
+// ChainRecord is a completed chain, links in activation order.
+type ChainRecord struct {
+	Turn  int
+	Links []*Link
+}
+
+// Chains tracks the chain being built or resolved and every completed
+// chain.
+//
+// Targets come from QTargets queries on a link's card and from
+// Meta_Selection events while the chain is being built; the YGOpen
+// protocol has no dedicated become-target event. Selections made to pay a
+// cost or for materials, discards and tributes are not targets and are
+// skipped by their reason. Negation events carry no
+// link number and apply to the link currently resolving, which is when the
+// core reports them.
+type Chains struct {
+	stack   []*Link // every link of the open chain, bottom first
+	solved  int     // links already popped from the top
+	history []*ChainRecord
+}
+
+// Current returns the links of the open chain, bottom first, including
+// links that already resolved. It is empty between chains.
+func (c *Chains) Current() []*Link {
+	return c.stack
+}
+
+// Top returns the highest link that has not resolved yet, or nil.
+func (c *Chains) Top() *Link {
+	if n := len(c.stack) - c.solved; n > 0 {
+		return c.stack[n-1]
+	}
+	return nil
+}
+
+// History returns every completed chain in order.
+func (c *Chains) History() []*ChainRecord {
+	return c.history
+}
+
+// Turn returns the chains completed during turn.
+func (c *Chains) Turn(turn int) []*ChainRecord {
+	var out []*ChainRecord
+	for _, r := range c.history {
+		if r.Turn == turn {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func (c *Chains) clone() *Chains {
+	cp := &Chains{solved: c.solved, history: slices.Clone(c.history)}
+	for _, l := range c.stack {
+		cp.stack = append(cp.stack, l.clone())
+	}
+	return cp
+}
+
+func (b *Board) pushChain(ch *duelpb.Chain) {
+	c := b.Chains
+	l := &Link{
+		Number:     len(c.stack) + 1,
+		Turn:       b.Turn,
+		Code:       ch.GetEffect().GetCode(),
+		Controller: int(ch.GetPlace().GetCon()),
+		CardPlace:  proto.Clone(ch.GetCardPlace()).(*duelpb.Place),
+		Place:      proto.Clone(ch.GetPlace()).(*duelpb.Place),
+		Effect:     proto.Clone(ch.GetEffect()).(*duelpb.Effect),
+	}
+	if card := b.At(ch.GetCardPlace()); card != nil && card.Code != 0 {
+		l.Code = card.Code
+	}
+	c.stack = append(c.stack, l)
+}
+
+func (b *Board) applyChainStack(ev *duelpb.Msg_Event_ChainStack) error {
+	c := b.Chains
+	switch t := ev.GetT().(type) {
+	case *duelpb.Msg_Event_ChainStack_Push:
+		if c.solved > 0 {
+			return fmt.Errorf("chain link pushed while the chain is resolving")
+		}
+		b.pushChain(t.Push)
+	case *duelpb.Msg_Event_ChainStack_Pop:
+		top := c.Top()
+		if top == nil {
+			return fmt.Errorf("chain pop with no open chain")
+		}
+		if top.Outcome == OutcomePending {
+			top.Outcome = OutcomeResolved
+		}
+		top.Solving = false
+		c.solved++
+		if c.solved == len(c.stack) {
+			c.history = append(c.history, &ChainRecord{Turn: c.stack[0].Turn, Links: c.stack})
+			c.stack, c.solved = nil, 0
+		}
+	}
+	return nil
+}
+
+func (b *Board) applyChainMeta(ev *duelpb.Msg_Event_Meta) {
+	top := b.Chains.Top()
+	if top == nil {
+		return
+	}
+	switch t := ev.GetT().(type) {
+	case *duelpb.Msg_Event_Meta_ChainStatus:
+		switch t.ChainStatus {
+		case duelpb.ChainStatus_CHAIN_STATUS_SOLVING:
+			top.Solving = true
+		case duelpb.ChainStatus_CHAIN_STATUS_ACT_NEGATED:
+			top.Outcome = OutcomeNegated
+		case duelpb.ChainStatus_CHAIN_STATUS_EFF_NEGATED:
+			top.Outcome = OutcomeDisabled
+		}
+	case *duelpb.Msg_Event_Meta_Selection_:
+		if b.Chains.solved == 0 && !top.Solving && t.Selection.GetReason()&notTargeting == 0 {
+			top.Targets = appendPlaces(top.Targets, t.Selection.GetSelectedPlaces())
+		}
+	}
+}
+
+// REASON_* bits of Meta_Selection.Reason that mark cards chosen for
+// something other than a target.
+const (
+	REASON_RELEASE  = 0x2
+	REASON_MATERIAL = 0x8
+	REASON_COST     = 0x80
+	REASON_DISCARD  = 0x4000
+)
+
+const notTargeting = REASON_RELEASE | REASON_MATERIAL | REASON_COST | REASON_DISCARD
+
+// applyChainQuery records QTargets of a card that is an open chain link.
+func (b *Board) applyChainQuery(q *duelpb.Msg_Query) {
+	for _, l := range b.Chains.stack {
+		if !proto.Equal(l.CardPlace, q.GetPlace()) {
+			continue
+		}
+		if t := q.GetData().GetTargets(); t != nil {
+			l.Targets = appendPlaces(nil, t.GetValues())
+		}
+		if code := q.GetData().GetCode(); code != nil && code.GetValue() != 0 && l.Code == 0 {
+			l.Code = code.GetValue()
+		}
+	}
+}
+
+func appendPlaces(dst, src []*duelpb.Place) []*duelpb.Place {
+	for _, p := range src {
+		dup := false
+		for _, d := range dst {
+			if proto.Equal(d, p) {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			dst = append(dst, proto.Clone(p).(*duelpb.Place))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,154 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Querier exposes the raw core queries; *bridge.Duel satisfies it.
+type Querier interface {
+	QueryField() ([]byte, error)
+	QueryLocation(con uint8, loc, flags uint32) ([]byte, error)
+}
+
+// Divergence is one difference between the board model and the engine.
+type Divergence struct {
+	Con   int
+	Loc   uint32
+	Seq   int // -1 for whole-pile or player values
+	Field string
+	Model string
+	Core  string
+}
+
+func (d Divergence) String() string {
+	where := fmt.Sprintf("p%d %s", d.Con, LocationName(d.Loc))
+	if d.Loc == 0 {
+		where = fmt.Sprintf("p%d", d.Con)
+	} else if d.Seq >= 0 {
+		where += fmt.Sprint(" ", d.Seq)
+	}
+	return fmt.Sprintf("%s: %s is %s in the model, %s in the core", where, d.Field, d.Model, d.Core)
+}
+
+// crossCheckLocations are queried card by card; the deck is only compared
+// by size because its order and codes are hidden from the model.
+var crossCheckLocations = []uint32{LOC_HAND, LOC_MZONE, LOC_SZONE, LOC_GRAVE, LOC_REMOVED, LOC_EXTRA}
+
+// CrossCheck compares b against the engine and returns every divergence:
+// life points, pile sizes, zone occupancy, positions, material counts, and
+// the code of every card the model knows. Cards whose code the model has
+// not learnt yet are not reported.
+func CrossCheck(b *Board, q Querier) ([]Divergence, error) {
+	raw, err := q.QueryField()
+	if err != nil {
+		return nil, err
+	}
+	field, err := ParseField(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse field query: %w", err)
+	}
+
+	var out []Divergence
+	diff := func(con int, loc uint32, seq int, what string, model, core any) {
+		if fmt.Sprint(model) != fmt.Sprint(core) {
+			out = append(out, Divergence{Con: con, Loc: loc, Seq: seq, Field: what,
+				Model: fmt.Sprint(model), Core: fmt.Sprint(core)})
+		}
+	}
+
+	for con, p := range b.Players {
+		fp := &field.Players[con]
+		diff(con, 0, -1, "LP", p.LP, fp.LP)
+		diff(con, LOC_DECK, -1, "count", len(p.Deck), fp.Deck)
+		diff(con, LOC_HAND, -1, "count", len(p.Hand), fp.Hand)
+		diff(con, LOC_GRAVE, -1, "count", len(p.Grave), fp.Grave)
+		diff(con, LOC_REMOVED, -1, "count", len(p.Banished), fp.Banished)
+		diff(con, LOC_EXTRA, -1, "count", len(p.Extra), fp.Extra)
+
+		for seq, z := range fp.Monsters {
+			c := p.Monsters[seq]
+			diff(con, LOC_MZONE, seq, "occupied", c != nil, z.Occupied)
+			if c != nil && z.Occupied {
+				diff(con, LOC_MZONE, seq, "position", positionName(c.Position), positionName(z.Position))
+				diff(con, LOC_MZONE, seq, "materials", len(p.Overlays[seq]), z.Materials)
+			}
+		}
+		for seq, z := range fp.Spells {
+			c := p.Spells[seq]
+			diff(con, LOC_SZONE, seq, "occupied", c != nil, z.Occupied)
+			if c != nil && z.Occupied {
+				diff(con, LOC_SZONE, seq, "position", positionName(c.Position), positionName(z.Position))
+			}
+		}
+
+		for _, loc := range crossCheckLocations {
+			raw, err := q.QueryLocation(uint8(con), loc, QUERY_CODE|QUERY_POSITION|QUERY_END)
+			if err != nil {
+				return nil, err
+			}
+			cards, err := ParseLocation(raw)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s query for player %d: %w", LocationName(loc), con, err)
+			}
+			model := b.cardsAt(con, loc)
+			for seq, qc := range cards {
+				if seq >= len(model) || qc == nil || model[seq] == nil {
+					continue
+				}
+				if model[seq].Code != 0 {
+					diff(con, loc, seq, "code", model[seq].Code, qc.Code)
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+// CheckAgainst is CrossCheck folded into a single error, for tests and
+// assertions.
+func CheckAgainst(b *Board, q Querier) error {
+	divs, err := CrossCheck(b, q)
+	if err != nil {
+		return err
+	}
+	if len(divs) == 0 {
+		return nil
+	}
+	lines := make([]string, len(divs))
+	for i, d := range divs {
+		lines[i] = d.String()
+	}
+	return errors.New("board diverges from core:\n  " + strings.Join(lines, "\n  "))
+}
+
+// cardsAt returns the model's cards at con/loc in sequence order, with nil
+// for empty zones.
+func (b *Board) cardsAt(con int, loc uint32) []*Card {
+	p := b.Players[con]
+	switch loc {
+	case LOC_MZONE:
+		return p.Monsters[:]
+	case LOC_SZONE:
+		return p.Spells[:]
+	}
+	if pile := p.Pile(loc); pile != nil {
+		return *pile
+	}
+	return nil
+}
+
+func positionName(pos uint32) string {
+	switch pos {
+	case POS_FACEUP_ATTACK:
+		return "face-up attack"
+	case POS_FACEDOWN_ATTACK:
+		return "face-down attack"
+	case POS_FACEUP_DEFENSE:
+		return "face-up defense"
+	case POS_FACEDOWN_DEFENSE:
+		return "face-down defense"
+	}
+	return fmt.Sprintf("0x%x", pos)
+}
@@ -0,0 +1,211 @@
+package state
+
+import (
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+/*
+   Query buffer builders
+*/
+
+// locationQuery encodes cards the way OCG_DuelQueryLocation does for
+// QUERY_CODE|QUERY_POSITION; nil cards are empty zones.
+func locationQuery(cards ...*QueriedCard) []byte {
+	var b []byte
+	field := func(flag uint32, payload ...byte) {
+		b = binary.LittleEndian.AppendUint16(b, uint16(4+len(payload)))
+		b = binary.LittleEndian.AppendUint32(b, flag)
+		b = append(b, payload...)
+	}
+	for _, c := range cards {
+		if c == nil {
+			b = binary.LittleEndian.AppendUint16(b, 0)
+			continue
+		}
+		field(QUERY_CODE, binary.LittleEndian.AppendUint32(nil, c.Code)...)
+		field(QUERY_POSITION, binary.LittleEndian.AppendUint32(nil, c.Position)...)
+		if c.Materials != nil {
+			payload := binary.LittleEndian.AppendUint32(nil, uint32(len(c.Materials)))
+			for _, m := range c.Materials {
+				payload = binary.LittleEndian.AppendUint32(payload, m)
+			}
+			field(QUERY_OVERLAY_CARD, payload...)
+		}
+		field(QUERY_END)
+	}
+	return b
+}
+
+// fieldQuery encodes f the way OCG_DuelQueryField does, with an optSize
+// byte options header and no chain links.
+func fieldQuery(f *FieldInfo, optSize int) []byte {
+	var b []byte
+	u32 := func(v uint32) { b = binary.LittleEndian.AppendUint32(b, v) }
+	b = binary.LittleEndian.AppendUint64(b, f.Options)[:optSize]
+	for _, p := range f.Players {
+		u32(p.LP)
+		zones := func(zs []FieldZone) {
+			for _, z := range zs {
+				if !z.Occupied {
+					b = append(b, 0)
+					continue
+				}
+				b = append(b, 1, byte(z.Position))
+				u32(uint32(z.Materials))
+			}
+		}
+		zones(p.Monsters[:])
+		zones(p.Spells[:])
+		for _, n := range []int{p.Deck, p.Hand, p.Grave, p.Banished, p.Extra, p.ExtraFaceUp} {
+			u32(uint32(n))
+		}
+	}
+	u32(0)
+	return b
+}
+
+func TestParseLocation(t *testing.T) {
+	want := []*QueriedCard{
+		{Code: 89631139, Position: POS_FACEUP_ATTACK},
+		nil,
+		{Code: 84013237, Position: POS_FACEUP_DEFENSE, Materials: []uint32{1, 2}},
+	}
+	buf := locationQuery(want...)
+
+	got, err := ParseLocation(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseLocation = %+v, want %+v", got, want)
+	}
+
+	prefixed := binary.LittleEndian.AppendUint32(nil, uint32(len(buf)))
+	got, err = ParseLocation(append(prefixed, buf...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseLocation with length prefix = %+v, want %+v", got, want)
+	}
+
+	if _, err := ParseLocation(buf[:len(buf)-6]); err == nil {
+		t.Error("ParseLocation accepted a card without QUERY_END")
+	}
+	if _, err := ParseLocation(buf[:len(buf)-1]); err == nil {
+		t.Error("ParseLocation accepted a truncated buffer")
+	}
+}
+
+func TestParseField(t *testing.T) {
+	want := &FieldInfo{Options: 0x5}
+	want.Players[0] = FieldPlayer{LP: 8000, Deck: 35, Hand: 4, Grave: 1, Extra: 15}
+	want.Players[0].Monsters[2] = FieldZone{Occupied: true, Position: POS_FACEUP_ATTACK, Materials: 2}
+	want.Players[0].Spells[SeqFieldZone] = FieldZone{Occupied: true, Position: POS_FACEDOWN_ATTACK}
+	want.Players[1] = FieldPlayer{LP: 6500, Deck: 33, Hand: 6, Banished: 2, Extra: 3, ExtraFaceUp: 1}
+
+	for _, optSize := range []int{4, 8} {
+		buf := fieldQuery(want, optSize)
+		got, err := ParseField(buf)
+		if err != nil {
+			t.Fatalf("%d-byte options: %v", optSize, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%d-byte options: ParseField = %+v, want %+v", optSize, got, want)
+		}
+		if _, err := ParseField(append(buf, 0, 0, 0)); err == nil {
+			t.Errorf("%d-byte options: ParseField accepted trailing bytes", optSize)
+		}
+	}
+}
+
+/*
+   CrossCheck
+*/
+
+// fakeCore answers queries from prepared buffers; locations it has no
+// buffer for are empty.
+type fakeCore struct {
+	field     *FieldInfo
+	locations map[[2]uint32][]*QueriedCard
+}
+
+func (f *fakeCore) QueryField() ([]byte, error) {
+	return fieldQuery(f.field, 8), nil
+}
+
+func (f *fakeCore) QueryLocation(con uint8, loc, flags uint32) ([]byte, error) {
+	return locationQuery(f.locations[[2]uint32{uint32(con), loc}]...), nil
+}
+
+// crossCheckBoard is a small mid-duel board and the core that agrees with
+// it.
+func crossCheckBoard() (*Board, *fakeCore) {
+	b := New()
+	p := b.Players[0]
+	p.LP = 8000
+	p.Deck = make([]*Card, 30)
+	for i := range p.Deck {
+		p.Deck[i] = &Card{}
+	}
+	p.Hand = []*Card{{Code: 55144522}, {}}
+	p.Monsters[2] = &Card{Code: 89631139, Position: POS_FACEUP_ATTACK}
+	p.Overlays[2] = []*Card{{Code: 1}}
+	p.Grave = []*Card{{Code: 12580477}}
+	b.Players[1].LP = 8000
+
+	core := &fakeCore{
+		field: &FieldInfo{},
+		locations: map[[2]uint32][]*QueriedCard{
+			{0, LOC_HAND}:  {{Code: 55144522}, {Code: 70368879}},
+			{0, LOC_MZONE}: {nil, nil, {Code: 89631139, Position: POS_FACEUP_ATTACK}, nil, nil, nil, nil},
+			{0, LOC_GRAVE}: {{Code: 12580477}},
+		},
+	}
+	core.field.Players[0] = FieldPlayer{LP: 8000, Deck: 30, Hand: 2, Grave: 1}
+	core.field.Players[0].Monsters[2] = FieldZone{Occupied: true, Position: POS_FACEUP_ATTACK, Materials: 1}
+	core.field.Players[1] = FieldPlayer{LP: 8000}
+	return b, core
+}
+
+func TestCrossCheckAgrees(t *testing.T) {
+	b, core := crossCheckBoard()
+	if err := CheckAgainst(b, core); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCrossCheckReportsDivergences(t *testing.T) {
+	b, core := crossCheckBoard()
+	core.field.Players[1].LP = 7000
+	core.field.Players[0].Deck = 29
+	core.field.Players[0].Monsters[2].Position = POS_FACEUP_DEFENSE
+	core.field.Players[0].Spells[1] = FieldZone{Occupied: true, Position: POS_FACEDOWN_ATTACK}
+	core.locations[[2]uint32{0, LOC_GRAVE}][0].Code = 83764718
+
+	divs, err := CrossCheck(b, core)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]string, len(divs))
+	for i, d := range divs {
+		got[i] = d.String()
+	}
+	want := []string{
+		"p0 deck: count is 30 in the model, 29 in the core",
+		"p0 mzone 2: position is face-up attack in the model, face-up defense in the core",
+		"p0 szone 1: occupied is false in the model, true in the core",
+		"p0 grave 0: code is 12580477 in the model, 83764718 in the core",
+		"p1: LP is 8000 in the model, 7000 in the core",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CrossCheck:\n  %s\nwant:\n  %s", strings.Join(got, "\n  "), strings.Join(want, "\n  "))
+	}
+
+	if err := CheckAgainst(b, core); err == nil || !strings.Contains(err.Error(), want[0]) {
+		t.Errorf("CheckAgainst = %v, want the divergences", err)
+	}
+}
@@ -0,0 +1,224 @@
+package state
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// QUERY_* flags accepted by OCG_DuelQueryLocation.
+const (
+	QUERY_CODE         = 0x1
+	QUERY_POSITION     = 0x2
+	QUERY_ALIAS        = 0x4
+	QUERY_TYPE         = 0x8
+	QUERY_LEVEL        = 0x10
+	QUERY_RANK         = 0x20
+	QUERY_ATTRIBUTE    = 0x40
+	QUERY_RACE         = 0x80
+	QUERY_ATTACK       = 0x100
+	QUERY_DEFENSE      = 0x200
+	QUERY_OVERLAY_CARD = 0x10000
+	QUERY_OWNER        = 0x40000
+	QUERY_IS_PUBLIC    = 0x100000
+	QUERY_END          = 0x80000000
+)
+
+var errShortQuery = errors.New("query buffer ends early")
+
+// QueriedCard is one card as reported by the core.
+type QueriedCard struct {
+	Code      uint32
+	Position  uint32
+	Owner     int
+	Public    bool
+	Materials []uint32
+}
+
+// ParseLocation decodes an OCG_DuelQueryLocation buffer. Empty zones are
+// returned as nil entries so indexes match sequences.
+//
+// Each card is a list of [u16 size][u32 flag][payload] fields closed by
+// QUERY_END; an empty zone is a lone zero size. Some core builds prefix the
+// buffer with its u32 length, which is skipped when present.
+func ParseLocation(buf []byte) ([]*QueriedCard, error) {
+	if len(buf) >= 4 && int(binary.LittleEndian.Uint32(buf)) == len(buf)-4 {
+		buf = buf[4:]
+	}
+
+	var cards []*QueriedCard
+	var cur *QueriedCard
+	for len(buf) > 0 {
+		if len(buf) < 2 {
+			return nil, errShortQuery
+		}
+		size := int(binary.LittleEndian.Uint16(buf))
+		buf = buf[2:]
+		if size == 0 {
+			if cur != nil {
+				return nil, fmt.Errorf("card %d has no QUERY_END", len(cards))
+			}
+			cards = append(cards, nil)
+			continue
+		}
+		if size < 4 || len(buf) < size {
+			return nil, errShortQuery
+		}
+		flag := binary.LittleEndian.Uint32(buf)
+		payload := buf[4:size]
+		buf = buf[size:]
+
+		if cur == nil {
+			cur = &QueriedCard{}
+		}
+		switch flag {
+		case QUERY_END:
+			cards = append(cards, cur)
+			cur = nil
+		case QUERY_CODE:
+			cur.Code = uint32(le(payload))
+		case QUERY_POSITION:
+			cur.Position = uint32(le(payload))
+		case QUERY_OWNER:
+			cur.Owner = int(le(payload))
+		case QUERY_IS_PUBLIC:
+			cur.Public = le(payload) != 0
+		case QUERY_OVERLAY_CARD:
+			if len(payload) < 4 {
+				return nil, errShortQuery
+			}
+			n := int(binary.LittleEndian.Uint32(payload))
+			if len(payload) < 4+4*n {
+				return nil, errShortQuery
+			}
+			for i := 0; i < n; i++ {
+				cur.Materials = append(cur.Materials, binary.LittleEndian.Uint32(payload[4+4*i:]))
+			}
+		}
+	}
+	if cur != nil {
+		return nil, fmt.Errorf("card %d has no QUERY_END", len(cards))
+	}
+	return cards, nil
+}
+
+// le reads a little-endian integer of the payload's width (1, 2, 4 or 8).
+func le(b []byte) uint64 {
+	var v uint64
+	for i := len(b) - 1; i >= 0; i-- {
+		v = v<<8 | uint64(b[i])
+	}
+	return v
+}
+
+// FieldZone is one monster or spell zone in a field query.
+type FieldZone struct {
+	Occupied  bool
+	Position  uint32
+	Materials int
+}
+
+// FieldPlayer is one player's part of a field query.
+type FieldPlayer struct {
+	LP       uint32
+	Monsters [MonsterZones]FieldZone
+	Spells   [SpellZones]FieldZone
+
+	Deck, Hand, Grave, Banished, Extra int
+	ExtraFaceUp                        int
+}
+
+// FieldInfo is a decoded OCG_DuelQueryField buffer.
+type FieldInfo struct {
+	Options uint64
+	Players [2]FieldPlayer
+	Chains  int
+}
+
+// ParseField decodes an OCG_DuelQueryField buffer. The duel options
+// header is 32 bits in older cores and 64 bits in newer ones; both layouts
+// are tried and the one that consumes the buffer exactly wins.
+func ParseField(buf []byte) (*FieldInfo, error) {
+	f, err := parseField(buf, 4)
+	if err == nil {
+		return f, nil
+	}
+	if f, err64 := parseField(buf, 8); err64 == nil {
+		return f, nil
+	}
+	return nil, err
+}
+
+func parseField(buf []byte, optSize int) (*FieldInfo, error) {
+	r := &queryReader{buf: buf}
+	f := &FieldInfo{Options: r.uint(optSize)}
+
+	for p := range f.Players {
+		fp := &f.Players[p]
+		fp.LP = uint32(r.uint(4))
+		zones := func(zs []FieldZone) {
+			for i := range zs {
+				if r.uint(1) == 0 {
+					continue
+				}
+				zs[i] = FieldZone{
+					Occupied:  true,
+					Position:  uint32(r.uint(1)),
+					Materials: int(r.uint(4)),
+				}
+			}
+		}
+		zones(fp.Monsters[:])
+		zones(fp.Spells[:])
+		fp.Deck = int(r.uint(4))
+		fp.Hand = int(r.uint(4))
+		fp.Grave = int(r.uint(4))
+		fp.Banished = int(r.uint(4))
+		fp.Extra = int(r.uint(4))
+		fp.ExtraFaceUp = int(r.uint(4))
+	}
+
+	f.Chains = int(r.uint(4))
+	for i := 0; i < f.Chains; i++ {
+		// code, controller, location, sequence, position, triggering
+		// controller, location, sequence, description
+		r.skip(4 + 1 + 1 + 4 + 4 + 1 + 1 + 4 + 8)
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+	if len(r.buf) != 0 {
+		return nil, fmt.Errorf("%d unread bytes after field query", len(r.buf))
+	}
+	return f, nil
+}
+
+type queryReader struct {
+	buf []byte
+	err error
+}
+
+func (r *queryReader) uint(n int) uint64 {
+	if r.err != nil {
+		return 0
+	}
+	if len(r.buf) < n {
+		r.err = errShortQuery
+		return 0
+	}
+	v := le(r.buf[:n])
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *queryReader) skip(n int) {
+	if r.err != nil {
+		return
+	}
+	if len(r.buf) < n {
+		r.err = errShortQuery
+		return
+	}
+	r.buf = r.buf[n:]
+}
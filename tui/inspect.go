@@ -0,0 +1,41 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/spb8026/ygo-visualizer/carddb"
+)
+
+// cardInfo describes a card for the inspection pane: name, type line,
+// stats and effect text, wrapped to width.
+func cardInfo(db carddb.Source, code uint32, width int) []string {
+	if code == 0 {
+		return []string{"Nothing to inspect here.", "", "Move the cursor to a card in the prompt, or Tab to your hand."}
+	}
+	if db == nil {
+		return []string{fmt.Sprintf("Card %d", code), "", "Start with -cdb to see card text."}
+	}
+	data, err := db.GetCard(code)
+	if err != nil || data == nil {
+		return []string{fmt.Sprintf("Card %d is not in the database.", code)}
+	}
+	text, err := db.GetText(code)
+	if err != nil || text == nil {
+		text = &carddb.CardText{Name: fmt.Sprintf("#%d", code)}
+	}
+
+	lines := []string{text.Name, fmt.Sprintf("%d", code), ""}
+	lines = append(lines, data.Line())
+	if data.IsMonster() {
+		if data.Type&carddb.TYPE_LINK != 0 {
+			lines = append(lines, fmt.Sprintf("ATK %d", data.Attack))
+		} else {
+			lines = append(lines, fmt.Sprintf("ATK %d  DEF %d", data.Attack, data.Defense))
+		}
+		if data.Type&carddb.TYPE_PENDULUM != 0 {
+			lines = append(lines, fmt.Sprintf("Scales %d/%d", data.Lscale, data.Rscale))
+		}
+	}
+	lines = append(lines, "")
+	return append(lines, wrap(text.Desc, width)...)
+}
@@ -0,0 +1,77 @@
+package tui
+
+import "unicode/utf8"
+
+// KeyCode identifies a key that is not a plain character.
+type KeyCode int
+
+const (
+	KeyRune KeyCode = iota
+	KeyUp
+	KeyDown
+	KeyLeft
+	KeyRight
+	KeyHome
+	KeyEnd
+	KeyPgUp
+	KeyPgDn
+	KeyEnter
+	KeyEsc
+	KeyTab
+	KeyBackspace
+	KeyCtrlC
+)
+
+// Key is one key press. Rune is set for KeyRune.
+type Key struct {
+	Code KeyCode
+	Rune rune
+}
+
+// escapes maps the sequences terminals send after ESC.
+var escapes = map[string]KeyCode{
+	"[A": KeyUp, "[B": KeyDown, "[C": KeyRight, "[D": KeyLeft,
+	"OA": KeyUp, "OB": KeyDown, "OC": KeyRight, "OD": KeyLeft,
+	"[H": KeyHome, "[F": KeyEnd, "OH": KeyHome, "OF": KeyEnd,
+	"[1~": KeyHome, "[4~": KeyEnd, "[7~": KeyHome, "[8~": KeyEnd,
+	"[5~": KeyPgUp, "[6~": KeyPgDn,
+}
+
+// decodeKeys splits one read from the terminal into key presses. A read
+// normally holds a whole escape sequence; an ESC followed by nothing it
+// recognises is reported as KeyEsc.
+func decodeKeys(buf []byte) []Key {
+	var keys []Key
+	for len(buf) > 0 {
+		switch b := buf[0]; b {
+		case 0x1b:
+			code, n := KeyEsc, 1
+			for seq, c := range escapes {
+				if len(buf) > len(seq) && string(buf[1:1+len(seq)]) == seq {
+					code, n = c, 1+len(seq)
+					break
+				}
+			}
+			keys = append(keys, Key{Code: code})
+			buf = buf[n:]
+			continue
+		case '\r', '\n':
+			keys = append(keys, Key{Code: KeyEnter})
+		case '\t':
+			keys = append(keys, Key{Code: KeyTab})
+		case 0x7f, 0x08:
+			keys = append(keys, Key{Code: KeyBackspace})
+		case 0x03:
+			keys = append(keys, Key{Code: KeyCtrlC})
+		default:
+			r, n := utf8.DecodeRune(buf)
+			if r >= ' ' {
+				keys = append(keys, Key{Code: KeyRune, Rune: r})
+			}
+			buf = buf[n:]
+			continue
+		}
+		buf = buf[1:]
+	}
+	return keys
+}
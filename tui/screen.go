@@ -0,0 +1,161 @@
+package tui
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/spb8026/ygo-visualizer/render"
+)
+
+type style int
+
+const (
+	styleNormal style = iota
+	styleBold
+	styleDim
+	styleReverse
+	styleError
+)
+
+var sgr = map[style]string{
+	styleNormal:  "\x1b[0m",
+	styleBold:    "\x1b[0;1m",
+	styleDim:     "\x1b[0;2m",
+	styleReverse: "\x1b[0;7m",
+	styleError:   "\x1b[0;1;31m",
+}
+
+// cell is one terminal column. A wide rune occupies its cell and the next,
+// which holds the zero rune.
+type cell struct {
+	r  rune
+	st style
+}
+
+// canvas is a frame drawn in memory and written to the terminal at once.
+type canvas struct {
+	w, h  int
+	cells [][]cell
+}
+
+func newCanvas(w, h int) *canvas {
+	c := &canvas{w: w, h: h, cells: make([][]cell, h)}
+	for y := range c.cells {
+		c.cells[y] = make([]cell, w)
+		for x := range c.cells[y] {
+			c.cells[y][x] = cell{r: ' '}
+		}
+	}
+	return c
+}
+
+// put writes s at x, y, clipped to the canvas and to maxw columns, and
+// returns the columns used.
+func (c *canvas) put(x, y, maxw int, s string, st style) int {
+	if y < 0 || y >= c.h {
+		return 0
+	}
+	used := 0
+	for _, r := range s {
+		rw := render.Width(string(r))
+		if used+rw > maxw || x+used+rw > c.w {
+			break
+		}
+		c.cells[y][x+used] = cell{r: r, st: st}
+		if rw == 2 {
+			c.cells[y][x+used+1] = cell{st: st}
+		}
+		used += rw
+	}
+	return used
+}
+
+// fill pads a line with styled blanks, for highlighted rows.
+func (c *canvas) fill(x, y, w int, st style) {
+	for i := 0; i < w && x+i < c.w && y >= 0 && y < c.h; i++ {
+		c.cells[y][x+i] = cell{r: ' ', st: st}
+	}
+}
+
+// box draws a pane border with a title. The focused pane has a bold
+// border.
+func (c *canvas) box(x, y, w, h int, title string, focused bool) {
+	st := styleDim
+	if focused {
+		st = styleBold
+	}
+	h1 := strings.Repeat("─", max(0, w-2))
+	c.put(x, y, w, "┌"+h1+"┐", st)
+	for i := 1; i < h-1; i++ {
+		c.put(x, y+i, 1, "│", st)
+		c.put(x+w-1, y+i, 1, "│", st)
+	}
+	c.put(x, y+h-1, w, "└"+h1+"┘", st)
+	if title != "" {
+		c.put(x+2, y, w-4, " "+title+" ", st)
+	}
+}
+
+// flush writes the whole frame, changing attributes only where they
+// change.
+func (c *canvas) flush(out io.Writer) error {
+	w := bufio.NewWriter(out)
+	w.WriteString("\x1b[H")
+	for y, row := range c.cells {
+		cur := style(-1)
+		if y > 0 {
+			w.WriteString("\r\n")
+		}
+		for _, cl := range row {
+			if cl.r == 0 {
+				continue
+			}
+			if cl.st != cur {
+				w.WriteString(sgr[cl.st])
+				cur = cl.st
+			}
+			w.WriteRune(cl.r)
+		}
+		w.WriteString(sgr[styleNormal])
+	}
+	return w.Flush()
+}
+
+// wrap breaks s into lines of at most w columns, at spaces where it can.
+func wrap(s string, w int) []string {
+	w = max(w, 2)
+	var out []string
+	for _, para := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			for render.Width(word) > w {
+				// Split words longer than the line, and CJK text without spaces.
+				head := ""
+				for _, r := range word {
+					if render.Width(head+string(r)) > w {
+						break
+					}
+					head += string(r)
+				}
+				if line != "" {
+					out = append(out, line)
+					line = ""
+				}
+				out = append(out, head)
+				word = word[len(head):]
+			}
+			switch {
+			case line == "":
+				line = word
+			case render.Width(line)+1+render.Width(word) <= w:
+				line += " " + word
+			default:
+				out = append(out, line)
+				line = word
+			}
+		}
+		out = append(out, line)
+	}
+	return out
+}
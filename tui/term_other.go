@@ -0,0 +1,13 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package tui
+
+import "fmt"
+
+func makeRaw(fd int) (func() error, error) {
+	return nil, fmt.Errorf("the full-screen interface needs a Unix terminal")
+}
+
+func terminalSize(fd int) (int, int, error) {
+	return 0, 0, fmt.Errorf("the full-screen interface needs a Unix terminal")
+}
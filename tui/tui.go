@@ -0,0 +1,382 @@
+// Package tui is a full-screen terminal interface for playing duels. The
+// screen holds four panes: the board, the hand of the player at the
+// bottom, the duel log, and a prompt where each kind of request gets its
+// own widget. Each player sees the board and log through fog, so hidden
+// cards stay hidden when two people share a terminal.
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spb8026/ygo-visualizer/carddb"
+	"github.com/spb8026/ygo-visualizer/fog"
+	"github.com/spb8026/ygo-visualizer/narrate"
+	"github.com/spb8026/ygo-visualizer/prompt"
+	"github.com/spb8026/ygo-visualizer/render"
+	"github.com/spb8026/ygo-visualizer/state"
+	duelpb "github.com/spb8026/ygo-visualizer/ygopenpb"
+)
+
+// ErrQuit is returned by Respond when the user quits.
+var ErrQuit = errors.New("quit")
+
+// FollowReplier is the Seat that turns the view to whoever has to answer,
+// for two players sharing a terminal.
+const FollowReplier = -1
+
+type Options struct {
+	DB        carddb.Source
+	Lang      string
+	Verbosity narrate.Verbosity
+	// Seat is the player drawn at the bottom, or FollowReplier.
+	Seat int
+	// In and Out default to the process's terminal.
+	In  *os.File
+	Out io.Writer
+}
+
+type pane int
+
+const (
+	panePrompt pane = iota
+	paneHand
+	paneLog
+)
+
+const helpLine = "↑↓ move · Enter choose · Space mark · Esc cancel · Tab pane · i inspect · q quit"
+
+// UI is one full-screen session. It is not safe for concurrent use.
+type UI struct {
+	db       carddb.Source
+	seat     int
+	in       *os.File
+	out      io.Writer
+	restore  func() error
+	audience *narrate.Audience
+
+	logs   [2][]string
+	bottom int
+
+	focus      pane
+	handCur    int
+	logScroll  int
+	inspecting bool
+	widget     widget
+	message    string
+	buf        [64]byte
+}
+
+// New switches the terminal to raw mode and the alternate screen. Close
+// restores it.
+func New(opts Options) (*UI, error) {
+	u := &UI{db: opts.DB, seat: opts.Seat, in: opts.In, out: opts.Out, audience: narrate.NewAudience()}
+	if u.in == nil {
+		u.in = os.Stdin
+	}
+	if u.out == nil {
+		u.out = os.Stdout
+	}
+	if u.seat == 1 {
+		u.bottom = 1
+	}
+	for _, v := range []fog.Viewer{fog.Player0, fog.Player1} {
+		if err := u.audience.Add(v, opts.DB, opts.Lang, opts.Verbosity); err != nil {
+			return nil, err
+		}
+	}
+
+	restore, err := makeRaw(int(u.in.Fd()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up the terminal: %w", err)
+	}
+	u.restore = restore
+	fmt.Fprint(u.out, "\x1b[?1049h\x1b[?25l")
+	return u, nil
+}
+
+// Close leaves the alternate screen and restores the terminal.
+func (u *UI) Close() error {
+	fmt.Fprint(u.out, "\x1b[0m\x1b[?25h\x1b[?1049l")
+	if u.restore != nil {
+		return u.restore()
+	}
+	return nil
+}
+
+// Feed shows one message of the duel.
+func (u *UI) Feed(m *duelpb.Msg) error {
+	heard, err := u.audience.Narrate(m)
+	if err != nil {
+		for con := range u.logs {
+			u.logs[con] = append(u.logs[con], "board error: "+err.Error())
+		}
+	}
+	for v, lines := range heard {
+		u.logs[v] = append(u.logs[v], lines...)
+	}
+	return u.draw()
+}
+
+// Respond shows req in the prompt pane and blocks until the user answers
+// or quits.
+func (u *UI) Respond(req *duelpb.Msg_Request) (*duelpb.Answer, error) {
+	replier := int(req.GetReplier() & 1)
+	if u.seat == FollowReplier {
+		u.bottom = replier
+		u.handCur = 0
+	}
+	v := fog.Viewer(replier)
+	p, err := prompt.For(req, u.audience.Board(v), u.db, u.audience.Catalog(v))
+	if err != nil {
+		return nil, err
+	}
+	u.widget, u.focus, u.message = newWidget(p, u.db), panePrompt, ""
+	defer func() { u.widget = nil }()
+
+	for {
+		if err := u.draw(); err != nil {
+			return nil, err
+		}
+		n, err := u.in.Read(u.buf[:])
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range decodeKeys(u.buf[:n]) {
+			ans, err := u.key(k)
+			if err != nil || ans != nil {
+				return ans, err
+			}
+		}
+	}
+}
+
+// Wait shows msg in the prompt pane until a key is pressed.
+func (u *UI) Wait(msg string) error {
+	u.message = msg
+	if err := u.draw(); err != nil {
+		return err
+	}
+	_, err := u.in.Read(u.buf[:])
+	return err
+}
+
+func (u *UI) key(k Key) (*duelpb.Answer, error) {
+	switch {
+	case k.Code == KeyCtrlC, k.Code == KeyRune && k.Rune == 'q':
+		return nil, ErrQuit
+	case k.Code == KeyTab:
+		u.focus = (u.focus + 1) % 3
+		return nil, nil
+	case k.Code == KeyRune && k.Rune == 'i':
+		u.inspecting = !u.inspecting
+		return nil, nil
+	}
+
+	switch u.focus {
+	case paneHand:
+		hand := u.hand()
+		switch k.Code {
+		case KeyLeft:
+			u.handCur = max(u.handCur-1, 0)
+		case KeyRight:
+			u.handCur = min(u.handCur+1, max(len(hand)-1, 0))
+		case KeyEsc, KeyEnter:
+			u.focus = panePrompt
+		}
+	case paneLog:
+		switch k.Code {
+		case KeyUp:
+			u.logScroll++
+		case KeyDown:
+			u.logScroll = max(u.logScroll-1, 0)
+		case KeyPgUp:
+			u.logScroll += 10
+		case KeyPgDn:
+			u.logScroll = max(u.logScroll-10, 0)
+		case KeyEnd:
+			u.logScroll = 0
+		case KeyEsc, KeyEnter:
+			u.focus = panePrompt
+		}
+	default:
+		ans, err := u.widget.key(k)
+		if err != nil {
+			u.message = err.Error()
+			return nil, nil
+		}
+		u.message = ""
+		return ans, nil
+	}
+	return nil, nil
+}
+
+func (u *UI) board() *state.Board {
+	return u.audience.Board(fog.Viewer(u.bottom))
+}
+
+func (u *UI) hand() []*state.Card {
+	return u.board().Players[u.bottom].Hand
+}
+
+// inspected is the card under the cursor of the focused pane.
+func (u *UI) inspected() uint32 {
+	if u.focus == paneHand {
+		if hand := u.hand(); u.handCur < len(hand) {
+			return hand[u.handCur].Code
+		}
+		return 0
+	}
+	if u.widget != nil {
+		return u.widget.code()
+	}
+	return 0
+}
+
+/*
+   Drawing
+*/
+
+func (u *UI) draw() error {
+	width, height, err := terminalSize(int(u.in.Fd()))
+	if err != nil || width < 40 || height < 16 {
+		width, height = max(width, 80), max(height, 24)
+	}
+	c := newCanvas(width, height)
+
+	var rows []string
+	if u.widget != nil {
+		rows = u.widget.rows()
+	}
+	promptH := min(max(len(rows)+4, 6), height/3)
+	handH := 4
+	boardH := height - promptH - handH
+	boardW, logW := width, 0
+	if width >= 100 {
+		boardW = width * 3 / 5
+		logW = width - boardW
+	}
+
+	if u.inspecting && logW == 0 {
+		u.drawSide(c, 0, 0, boardW, boardH)
+	} else {
+		u.drawBoard(c, 0, 0, boardW, boardH)
+	}
+	u.drawHand(c, 0, boardH, boardW, handH)
+	if logW > 0 {
+		u.drawSide(c, boardW, 0, logW, boardH+handH)
+	}
+	u.drawPrompt(c, 0, boardH+handH, width, promptH, rows)
+	return c.flush(u.out)
+}
+
+func (u *UI) drawBoard(c *canvas, x, y, w, h int) {
+	b := u.board()
+	c.box(x, y, w, h, fmt.Sprintf("Board · %s", u.audience.Catalog(fog.Viewer(u.bottom)).PlayerName(u.bottom)), false)
+	opts := render.TextOptions{Width: w - 2, DB: u.db, Bottom: u.bottom, OmitHands: true}
+	// A boxed board needs 23 lines plus one per chain link.
+	opts.Compact = h-2 < 23+len(b.Chains.Current())
+	lines := strings.Split(strings.TrimRight(render.Text(b, opts), "\n"), "\n")
+	for i, line := range lines {
+		if i >= h-2 {
+			break
+		}
+		c.put(x+1, y+1+i, w-2, line, styleNormal)
+	}
+}
+
+func (u *UI) drawHand(c *canvas, x, y, w, h int) {
+	hand := u.hand()
+	u.handCur = min(u.handCur, max(len(hand)-1, 0))
+	c.box(x, y, w, h, fmt.Sprintf("Hand (%d)", len(hand)), u.focus == paneHand)
+	cx, cy := x+1, y+1
+	for i, card := range hand {
+		name := render.CardName(card.Code, u.db)
+		if cx > x+1 && cx+render.Width(name) > x+w-1 {
+			cx, cy = x+1, cy+1
+		}
+		if cy >= y+h-1 {
+			break
+		}
+		st := styleNormal
+		if u.focus == paneHand && i == u.handCur {
+			st = styleReverse
+		}
+		cx += c.put(cx, cy, x+w-1-cx, name, st) + 2
+	}
+}
+
+// drawSide draws the log, or the inspected card's text.
+func (u *UI) drawSide(c *canvas, x, y, w, h int) {
+	if u.inspecting {
+		c.box(x, y, w, h, "Card", true)
+		for i, line := range cardInfo(u.db, u.inspected(), w-2) {
+			if i >= h-2 {
+				break
+			}
+			st := styleNormal
+			if i == 0 {
+				st = styleBold
+			}
+			c.put(x+1, y+1+i, w-2, line, st)
+		}
+		return
+	}
+
+	var lines []string
+	for _, entry := range u.logs[u.bottom] {
+		lines = append(lines, wrap(entry, w-2)...)
+	}
+	visible := h - 2
+	u.logScroll = min(u.logScroll, max(len(lines)-visible, 0))
+	end := len(lines) - u.logScroll
+	start := max(end-visible, 0)
+	title := "Log"
+	if u.logScroll > 0 {
+		title = fmt.Sprintf("Log (%d more below)", u.logScroll)
+	}
+	c.box(x, y, w, h, title, u.focus == paneLog)
+	for i, line := range lines[start:end] {
+		c.put(x+1, y+1+i, w-2, line, styleNormal)
+	}
+}
+
+func (u *UI) drawPrompt(c *canvas, x, y, w, h int, rows []string) {
+	if u.widget == nil {
+		c.box(x, y, w, h, "Waiting", false)
+		if u.message != "" {
+			c.put(x+2, y+1, w-4, u.message, styleBold)
+		}
+		return
+	}
+	who := u.audience.Catalog(fog.Viewer(u.bottom)).PlayerName(u.bottom)
+	c.box(x, y, w, h, fmt.Sprintf("%s · %s", u.widget.title(), who), u.focus == panePrompt)
+	c.put(x+2, y+1, w-4, u.widget.question(), styleBold)
+
+	// Scroll the options so the cursor stays visible.
+	visible := h - 4
+	start := 0
+	if cur := u.widget.cursor(); cur >= visible {
+		start = cur - visible + 1
+	}
+	for i := start; i < len(rows) && i-start < visible; i++ {
+		st := styleNormal
+		if i == u.widget.cursor() && u.focus == panePrompt {
+			st = styleReverse
+			c.fill(x+1, y+2+i-start, w-2, st)
+		}
+		c.put(x+2, y+2+i-start, w-4, rows[i], st)
+	}
+
+	status, st := u.widget.status(), styleDim
+	if u.message != "" {
+		status, st = u.message, styleError
+	}
+	if status != "" {
+		status += " · "
+	}
+	c.put(x+2, y+h-2, w-4, status+helpLine, st)
+}
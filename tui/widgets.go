@@ -0,0 +1,252 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spb8026/ygo-visualizer/carddb"
+	"github.com/spb8026/ygo-visualizer/prompt"
+	duelpb "github.com/spb8026/ygo-visualizer/ygopenpb"
+)
+
+// widget collects the answer to one request.
+type widget interface {
+	// title names the request; question is the full prompt.
+	title() string
+	question() string
+	// rows are the lines listed under the question; cursor is the
+	// highlighted one, or -1.
+	rows() []string
+	cursor() int
+	// status summarises the selection so far.
+	status() string
+	// key handles a key press. It returns the answer once the selection is
+	// complete, or an error explaining why it is not acceptable yet.
+	key(k Key) (*duelpb.Answer, error)
+	// code is the card under the cursor, for inspection, or 0.
+	code() uint32
+}
+
+// newWidget picks the widget that collects an answer to p.
+func newWidget(p *prompt.Prompt, db carddb.Source) widget {
+	if p.Mode == prompt.DeclareCode {
+		return &codeInput{p: p, db: db}
+	}
+	return &picker{p: p, values: make([]int, len(p.Options))}
+}
+
+// picker is the list widget behind every prompt except card code
+// declaration.
+type picker struct {
+	p   *prompt.Prompt
+	cur int
+	// values holds 1 for a marked PickMany option, the 1-based position of
+	// a PickOrder option, or the amount of a PickAmounts option.
+	values []int
+}
+
+func (p *picker) title() string    { return p.p.Title }
+func (p *picker) question() string { return p.p.Question }
+func (p *picker) cursor() int      { return p.cur }
+
+func (p *picker) code() uint32 {
+	if p.cur < len(p.p.Options) {
+		return p.p.Options[p.cur].Code
+	}
+	return 0
+}
+
+func (p *picker) rows() []string {
+	out := make([]string, len(p.p.Options))
+	for i, o := range p.p.Options {
+		v := p.values[i]
+		switch p.p.Mode {
+		case prompt.PickOne:
+			out[i] = fmt.Sprintf("%2d. %s", i+1, o.Label)
+		case prompt.PickMany:
+			mark := " "
+			if v > 0 {
+				mark = "x"
+			}
+			out[i] = fmt.Sprintf("[%s] %s", mark, o.Label)
+		case prompt.PickOrder:
+			pos := "  "
+			if v > 0 {
+				pos = fmt.Sprintf("%2d", v)
+			}
+			out[i] = fmt.Sprintf("(%s) %s", pos, o.Label)
+		case prompt.PickAmounts:
+			out[i] = fmt.Sprintf("‹%2d/%d› %s", v, o.Limit, o.Label)
+		}
+	}
+	return out
+}
+
+func (p *picker) status() string {
+	n := len(p.marked())
+	switch p.p.Mode {
+	case prompt.PickMany:
+		if p.p.Min == p.p.Max {
+			return fmt.Sprintf("%d of %d marked", n, p.p.Min)
+		}
+		return fmt.Sprintf("%d marked (%d to %d)", n, p.p.Min, p.p.Max)
+	case prompt.PickOrder:
+		return fmt.Sprintf("%d of %d ordered · Space picks the next card", n, len(p.p.Options))
+	case prompt.PickAmounts:
+		total := 0
+		for _, v := range p.values {
+			total += v
+		}
+		return fmt.Sprintf("%d of %d · Left and Right change the amount", total, p.p.Max)
+	}
+	return ""
+}
+
+// marked returns the marked options, in pick order for PickOrder.
+func (p *picker) marked() []int {
+	var sel []int
+	switch p.p.Mode {
+	case prompt.PickMany:
+		for i, v := range p.values {
+			if v > 0 {
+				sel = append(sel, i)
+			}
+		}
+	case prompt.PickOrder:
+		for pos := 1; pos <= len(p.values); pos++ {
+			for i, v := range p.values {
+				if v == pos {
+					sel = append(sel, i)
+				}
+			}
+		}
+	}
+	return sel
+}
+
+func (p *picker) key(k Key) (*duelpb.Answer, error) {
+	n := len(p.p.Options)
+	switch k.Code {
+	case KeyUp:
+		p.cur = (p.cur + n - 1) % max(n, 1)
+	case KeyDown:
+		p.cur = (p.cur + 1) % max(n, 1)
+	case KeyHome, KeyPgUp:
+		p.cur = 0
+	case KeyEnd, KeyPgDn:
+		p.cur = max(n-1, 0)
+	case KeyLeft, KeyRight:
+		if p.p.Mode == prompt.PickAmounts && n > 0 {
+			v := &p.values[p.cur]
+			if k.Code == KeyLeft && *v > 0 {
+				*v--
+			} else if k.Code == KeyRight && *v < p.p.Options[p.cur].Limit {
+				*v++
+			}
+		}
+	case KeyRune:
+		switch {
+		case k.Rune == ' ':
+			p.toggle()
+		case k.Rune >= '1' && k.Rune <= '9' && int(k.Rune-'1') < n:
+			p.cur = int(k.Rune - '1')
+		}
+	case KeyEsc:
+		return p.p.Cancel()
+	case KeyEnter:
+		return p.finish()
+	}
+	return nil, nil
+}
+
+func (p *picker) toggle() {
+	if len(p.values) == 0 {
+		return
+	}
+	v := &p.values[p.cur]
+	switch p.p.Mode {
+	case prompt.PickMany:
+		if *v == 0 && len(p.marked()) >= p.p.Max {
+			return
+		}
+		*v = 1 - *v
+	case prompt.PickOrder:
+		if *v == 0 {
+			*v = len(p.marked()) + 1
+			return
+		}
+		// Unpicking closes the gap it leaves.
+		for i := range p.values {
+			if p.values[i] > *v {
+				p.values[i]--
+			}
+		}
+		*v = 0
+	}
+}
+
+func (p *picker) finish() (*duelpb.Answer, error) {
+	var sel []int
+	switch p.p.Mode {
+	case prompt.PickOne:
+		sel = []int{p.cur}
+	case prompt.PickMany:
+		sel = p.marked()
+		// Enter alone takes the cursor when a single card is wanted.
+		if len(sel) == 0 && p.p.Max == 1 {
+			sel = []int{p.cur}
+		}
+	case prompt.PickOrder:
+		sel = p.marked()
+	case prompt.PickAmounts:
+		sel = p.values
+	}
+	return p.p.Answer(sel)
+}
+
+// codeInput reads a card code for a DeclareCode prompt.
+type codeInput struct {
+	p    *prompt.Prompt
+	text string
+	db   carddb.Source
+}
+
+func (c *codeInput) title() string    { return c.p.Title }
+func (c *codeInput) question() string { return c.p.Question }
+func (c *codeInput) cursor() int      { return -1 }
+func (c *codeInput) status() string   { return "type a card code, Enter to declare" }
+
+func (c *codeInput) value() uint32 {
+	n, _ := strconv.ParseUint(c.text, 10, 32)
+	return uint32(n)
+}
+
+func (c *codeInput) code() uint32 {
+	return c.value()
+}
+
+func (c *codeInput) rows() []string {
+	rows := []string{"Code: " + c.text + "_"}
+	if code := c.value(); code != 0 && c.db != nil {
+		if text, err := c.db.GetText(code); err == nil && text != nil {
+			rows = append(rows, "      "+text.Name)
+		}
+	}
+	return rows
+}
+
+func (c *codeInput) key(k Key) (*duelpb.Answer, error) {
+	switch k.Code {
+	case KeyRune:
+		if k.Rune >= '0' && k.Rune <= '9' && len(c.text) < 10 {
+			c.text += string(k.Rune)
+		}
+	case KeyBackspace:
+		if c.text != "" {
+			c.text = c.text[:len(c.text)-1]
+		}
+	case KeyEnter:
+		return c.p.Declare(c.value())
+	}
+	return nil, nil
+}
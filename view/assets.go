@@ -0,0 +1,14 @@
+package view
+
+import _ "embed"
+
+// Script defines YGOView, which draws Snapshots in a browser. Pages inline
+// it or serve it as-is.
+//
+//go:embed board.js
+var Script string
+
+// Style is the stylesheet Script's boards expect.
+//
+//go:embed board.css
+var Style string
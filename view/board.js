@@ -0,0 +1,232 @@
+// board.js draws the boards of package view: YGOView.draw renders one
+// Snapshot into an element, and YGOView.tooltips shows card text when a
+// card is hovered. It has no dependencies, so pages can inline it.
+"use strict";
+
+var YGOView = (function () {
+  var ROWS = 5, COLS = 7, ROW_EMZ = 2;
+  var LOC_MZONE = 0x4, LOC_HAND = 0x2;
+
+  function el(tag, cls, text) {
+    var e = document.createElement(tag);
+    if (cls) e.className = cls;
+    if (text != null) e.textContent = text;
+    return e;
+  }
+
+  function playerName(con) {
+    return "Player " + (con + 1);
+  }
+
+  function cardName(code, cards) {
+    if (!code) return "?";
+    var info = cards[code];
+    return info ? info.name : "#" + code;
+  }
+
+  // stats formats ATK/DEF, or ATK/LINK-n, from the card as it is now.
+  function stats(c, info) {
+    if (!info || !info.monster) return "";
+    var atk = c.atk || 0;
+    if (info.link) return atk + "/LINK-" + (c.rating || info.rating || 0);
+    return atk + "/" + (c.def || 0);
+  }
+
+  // card builds one card. Cards the viewer cannot see show their back.
+  function card(c, cards) {
+    var e = el("div", "yv-card");
+    if (!c.code) {
+      e.classList.add("yv-back");
+      return e;
+    }
+    var info = cards[c.code];
+    e.classList.add("yv-" + (info ? info.kind : "unknown"));
+    if (c.faceDown) e.classList.add("yv-down");
+    if (c.defense) e.classList.add("yv-def");
+    e.dataset.code = c.code;
+    e.appendChild(el("div", "yv-name", cardName(c.code, cards)));
+    if (info && info.monster) {
+      var r = c.rating || info.rating || 0;
+      e.appendChild(el("div", "yv-rating", info.link ? "" : (info.kind === "xyz" ? "Rank " : "Lv ") + r));
+      e.appendChild(el("div", "yv-stats", stats(c, info)));
+    }
+    (c.counters || []).forEach(function (ctr) {
+      var b = el("span", "yv-counter", String(ctr.count));
+      b.title = "Counter 0x" + ctr.type.toString(16) + ": " + ctr.count;
+      e.appendChild(b);
+    });
+    return e;
+  }
+
+  function names(list, cards) {
+    return list.map(function (c) { return cardName(c.code, cards); }).join(", ");
+  }
+
+  function bar(s, con, cards) {
+    var p = s.players[con];
+    var e = el("div", "yv-bar" + (s.turnPlayer === con ? " yv-turn" : ""));
+    e.appendChild(el("span", "yv-player", playerName(con)));
+    var lp = el("span", "yv-lp");
+    var fill = el("span", "yv-lp-fill");
+    fill.style.width = Math.min(p.lp, 8000) / 80 + "%";
+    fill.classList.add(p.lp > 4000 ? "yv-lp-high" : p.lp > 2000 ? "yv-lp-mid" : "yv-lp-low");
+    lp.appendChild(fill);
+    e.appendChild(lp);
+    e.appendChild(el("span", "yv-lp-text", "LP " + p.lp));
+    var counts = el("span", "yv-counts",
+      "Hand " + p.hand.length + " · Deck " + p.deck + " · Extra " + p.extra +
+      " · GY " + p.grave.length + " · Banished " + p.banished.length);
+    var lists = [];
+    if (p.grave.length) lists.push("GY: " + names(p.grave, cards));
+    if (p.banished.length) lists.push("Banished: " + names(p.banished, cards));
+    counts.title = lists.join("\n");
+    e.appendChild(counts);
+    return e;
+  }
+
+  function hand(s, con, cards) {
+    var e = el("div", "yv-hand");
+    e.dataset.con = con;
+    s.players[con].hand.forEach(function (c, i) {
+      var ce = card(c, cards);
+      ce.dataset.con = con;
+      ce.dataset.loc = LOC_HAND;
+      ce.dataset.seq = i;
+      e.appendChild(ce);
+    });
+    return e;
+  }
+
+  function zone(s, z, cards) {
+    var e = el("div", "yv-zone yv-con" + z.con);
+    e.dataset.con = z.con;
+    e.dataset.loc = z.loc;
+    e.dataset.seq = z.seq;
+    e.appendChild(el("span", "yv-label", z.label));
+    if (z.card) {
+      var ce = card(z.card, cards);
+      if (z.count && z.loc === LOC_MZONE) ce.classList.add("yv-materials");
+      e.appendChild(ce);
+    }
+    if (z.count) {
+      e.appendChild(el("span", "yv-count", String(z.count)));
+    }
+    if (z.pile && z.count) {
+      var list = z.label === "GY" ? s.players[z.con].grave : null;
+      e.title = z.label + ": " + z.count + " cards" + (list ? "\n" + names(list, cards) : "");
+    }
+    return e;
+  }
+
+  function grid(s, bottom, cards) {
+    var e = el("div", "yv-grid");
+    var rows = [];
+    for (var r = 0; r < ROWS; r++) {
+      if (r !== ROW_EMZ || s.emz) rows.push(r);
+    }
+    e.style.gridTemplateRows = "repeat(" + rows.length + ", var(--yv-cell))";
+    s.zones.forEach(function (z) {
+      var row = z.row, col = z.col;
+      if (bottom === 1) {
+        row = ROWS - 1 - row;
+        col = COLS - 1 - col;
+      }
+      var ze = zone(s, z, cards);
+      ze.style.gridRow = rows.indexOf(row) + 1;
+      ze.style.gridColumn = col + 1;
+      e.appendChild(ze);
+    });
+    return e;
+  }
+
+  function chain(s, cards) {
+    var e = el("div", "yv-chain");
+    e.appendChild(el("div", "yv-chain-title", s.chain && s.chain.length ? "Chain" : "No chain"));
+    (s.chain || []).slice().reverse().forEach(function (l) {
+      var le = el("div", "yv-chain-link yv-" + l.outcome + (l.solving ? " yv-solving" : ""));
+      le.appendChild(el("span", "yv-chain-number", String(l.number)));
+      var name = el("span", "yv-chain-name", cardName(l.code, cards));
+      if (l.code) name.dataset.code = l.code;
+      le.appendChild(name);
+      var status = playerName(l.controller);
+      if (l.outcome !== "pending") status += " · " + l.outcome;
+      else if (l.solving) status += " · resolving";
+      if (l.targets && l.targets.length) status += " · " + l.targets.length + " targets";
+      le.appendChild(el("span", "yv-chain-status", status));
+      e.appendChild(le);
+    });
+    return e;
+  }
+
+  // mark adds cls to the cards or zones at the given places.
+  function mark(root, places, cls) {
+    places.forEach(function (p) {
+      var sel = p.loc === LOC_HAND
+        ? '.yv-hand [data-con="' + p.con + '"][data-seq="' + p.seq + '"]'
+        : '.yv-zone[data-con="' + p.con + '"][data-loc="' + p.loc + '"][data-seq="' + p.seq + '"]';
+      root.querySelectorAll(sel).forEach(function (n) { n.classList.add(cls); });
+    });
+  }
+
+  // draw replaces the contents of root with snapshot s, seen with player
+  // bottom at the bottom of the screen.
+  function draw(root, s, cards, bottom) {
+    var top = 1 - bottom;
+    root.textContent = "";
+    var board = el("div", "yv-board");
+    var main = el("div", "yv-main");
+    main.appendChild(el("div", "yv-header",
+      "Turn " + s.turn + " · " + playerName(s.turnPlayer) + " · " + s.phase));
+    main.appendChild(hand(s, top, cards));
+    main.appendChild(bar(s, top, cards));
+    main.appendChild(grid(s, bottom, cards));
+    main.appendChild(bar(s, bottom, cards));
+    main.appendChild(hand(s, bottom, cards));
+    board.appendChild(main);
+    board.appendChild(chain(s, cards));
+    root.appendChild(board);
+
+    var open = (s.chain || []).filter(function (l) { return l.outcome === "pending"; });
+    if (open.length) {
+      var last = open[open.length - 1];
+      if (last.place) mark(root, [last.place], "yv-active");
+      mark(root, last.targets || [], "yv-target");
+    }
+  }
+
+  // tooltips shows the text of any card under the mouse inside root.
+  // cards is called for the current dictionary.
+  function tooltips(root, cards) {
+    var tip = el("div", "yv-tip");
+    tip.hidden = true;
+    document.body.appendChild(tip);
+    root.addEventListener("mouseover", function (ev) {
+      var t = ev.target.closest("[data-code]");
+      var info = t && cards()[t.dataset.code];
+      if (!info) {
+        tip.hidden = true;
+        return;
+      }
+      tip.textContent = "";
+      tip.appendChild(el("div", "yv-tip-name", info.name));
+      if (info.line) tip.appendChild(el("div", "yv-tip-line", info.line));
+      if (info.monster) {
+        tip.appendChild(el("div", "yv-tip-stats",
+          info.link ? "ATK " + info.atk + " · LINK-" + info.rating : "ATK " + info.atk + " · DEF " + info.def));
+      }
+      if (info.text) tip.appendChild(el("div", "yv-tip-text", info.text));
+      tip.hidden = false;
+    });
+    root.addEventListener("mousemove", function (ev) {
+      if (tip.hidden) return;
+      var x = ev.clientX + 16, y = ev.clientY + 16;
+      if (x + tip.offsetWidth > window.innerWidth) x = ev.clientX - tip.offsetWidth - 16;
+      if (y + tip.offsetHeight > window.innerHeight) y = Math.max(0, window.innerHeight - tip.offsetHeight);
+      tip.style.left = x + "px";
+      tip.style.top = y + "px";
+    });
+    root.addEventListener("mouseleave", function () { tip.hidden = true; });
+  }
+
+  return { draw: draw, tooltips: tooltips, playerName: playerName, cardName: cardName };
+})();
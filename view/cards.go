@@ -0,0 +1,83 @@
+package view
+
+import (
+	"github.com/spb8026/ygo-visualizer/carddb"
+	"github.com/spb8026/ygo-visualizer/render"
+)
+
+// CardInfo is what a viewer shows when a card is hovered.
+type CardInfo struct {
+	Name string `json:"name"`
+	Line string `json:"line,omitempty"`
+	Text string `json:"text,omitempty"`
+	// Kind picks the frame color: "effect", "normal", "fusion", "ritual",
+	// "synchro", "xyz", "link", "token", "spell", "trap" or "unknown".
+	Kind    string `json:"kind"`
+	Monster bool   `json:"monster,omitempty"`
+	Link    bool   `json:"link,omitempty"`
+	Atk     int32  `json:"atk,omitempty"`
+	Def     int32  `json:"def,omitempty"`
+	Rating  int32  `json:"rating,omitempty"`
+}
+
+// Cards is a dictionary of the cards a viewer has been shown, keyed by code.
+type Cards map[uint32]*CardInfo
+
+// Add looks up code in db unless it is already known. db may be nil, in
+// which case cards are named by code.
+func (cs Cards) Add(code uint32, db carddb.Source) {
+	if code == 0 || cs[code] != nil {
+		return
+	}
+	info := &CardInfo{Name: render.CardName(code, db), Kind: "unknown"}
+	cs[code] = info
+	if db == nil {
+		return
+	}
+	data, err := db.GetCard(code)
+	if err != nil || data == nil {
+		return
+	}
+	info.Line = data.Line()
+	info.Kind = kindOf(data.Type)
+	if data.IsMonster() {
+		info.Monster = true
+		info.Link = data.Type&carddb.TYPE_LINK != 0
+		info.Atk, info.Def, info.Rating = data.Attack, data.Defense, int32(data.Level)
+	}
+	if text, err := db.GetText(code); err == nil && text != nil {
+		info.Text = text.Desc
+	}
+}
+
+// AddSnapshot adds every card s shows.
+func (cs Cards) AddSnapshot(s *Snapshot, db carddb.Source) {
+	for _, code := range s.Codes() {
+		cs.Add(code, db)
+	}
+}
+
+// kindOf follows the frame colors of render.
+func kindOf(t uint32) string {
+	switch {
+	case t&carddb.TYPE_SPELL != 0:
+		return "spell"
+	case t&carddb.TYPE_TRAP != 0:
+		return "trap"
+	case t&carddb.TYPE_TOKEN != 0:
+		return "token"
+	case t&carddb.TYPE_LINK != 0:
+		return "link"
+	case t&carddb.TYPE_XYZ != 0:
+		return "xyz"
+	case t&carddb.TYPE_SYNCHRO != 0:
+		return "synchro"
+	case t&carddb.TYPE_FUSION != 0:
+		return "fusion"
+	case t&carddb.TYPE_RITUAL != 0:
+		return "ritual"
+	case t&carddb.TYPE_NORMAL != 0:
+		return "normal"
+	}
+	return "effect"
+}
@@ -0,0 +1,193 @@
+// Package view turns boards into JSON snapshots for browser viewers, and
+// holds the script and styles that draw them. The HTML replay exporter and
+// the live server both use it, so a board looks the same in either.
+package view
+
+import (
+	"github.com/spb8026/ygo-visualizer/carddb"
+	"github.com/spb8026/ygo-visualizer/narrate"
+	"github.com/spb8026/ygo-visualizer/render"
+	"github.com/spb8026/ygo-visualizer/state"
+	duelpb "github.com/spb8026/ygo-visualizer/ygopenpb"
+)
+
+// Snapshot is one board as a viewer sees it.
+type Snapshot struct {
+	Turn       int       `json:"turn"`
+	TurnPlayer int       `json:"turnPlayer"`
+	Phase      string    `json:"phase"`
+	Players    [2]Player `json:"players"`
+	// Zones are the cells of render.Grid, in player 0's view.
+	Zones []Zone `json:"zones"`
+	// EMZ reports whether the field has Extra Monster Zones.
+	EMZ   bool   `json:"emz"`
+	Chain []Link `json:"chain,omitempty"`
+}
+
+type Player struct {
+	LP       uint32 `json:"lp"`
+	Hand     []Card `json:"hand"`
+	Deck     int    `json:"deck"`
+	Extra    int    `json:"extra"`
+	Grave    []Card `json:"grave"`
+	Banished []Card `json:"banished"`
+}
+
+type Zone struct {
+	Row   int    `json:"row"`
+	Col   int    `json:"col"`
+	Con   int    `json:"con"`
+	Loc   uint32 `json:"loc"`
+	Seq   int    `json:"seq"`
+	Label string `json:"label"`
+	Pile  bool   `json:"pile,omitempty"`
+	Card  *Card  `json:"card,omitempty"`
+	// Count is the size of a pile, or the number of Xyz materials.
+	Count int `json:"count,omitempty"`
+}
+
+// Card is a card as drawn. Code is 0 for a card the viewer cannot see.
+type Card struct {
+	Code     uint32    `json:"code,omitempty"`
+	FaceDown bool      `json:"faceDown,omitempty"`
+	Defense  bool      `json:"defense,omitempty"`
+	Atk      int32     `json:"atk,omitempty"`
+	Def      int32     `json:"def,omitempty"`
+	Rating   int32     `json:"rating,omitempty"`
+	Counters []Counter `json:"counters,omitempty"`
+}
+
+type Counter struct {
+	Type  uint32 `json:"type"`
+	Count uint32 `json:"count"`
+}
+
+type Place struct {
+	Con int    `json:"con"`
+	Loc uint32 `json:"loc"`
+	Seq int    `json:"seq"`
+}
+
+type Link struct {
+	Number     int     `json:"number"`
+	Code       uint32  `json:"code,omitempty"`
+	Controller int     `json:"controller"`
+	Place      *Place  `json:"place,omitempty"`
+	Targets    []Place `json:"targets,omitempty"`
+	// Outcome is "pending", "resolved", "negated" or "disabled".
+	Outcome string `json:"outcome"`
+	Solving bool   `json:"solving,omitempty"`
+}
+
+// Snap describes b. cat names the phase; nil means English. db fills in
+// printed stats the board does not have yet.
+func Snap(b *state.Board, db carddb.Source, cat *narrate.Catalog) *Snapshot {
+	s := &Snapshot{Turn: b.Turn, TurnPlayer: b.TurnPlayer, EMZ: b.HasEMZ()}
+	if cat != nil {
+		s.Phase = cat.PhaseName(b.Phase)
+	} else {
+		s.Phase = narrate.PhaseName(b.Phase)
+	}
+
+	for con, p := range b.Players {
+		s.Players[con] = Player{
+			LP:       p.LP,
+			Hand:     held(p.Hand, db),
+			Deck:     len(p.Deck),
+			Extra:    len(p.Extra),
+			Grave:    held(p.Grave, db),
+			Banished: held(p.Banished, db),
+		}
+	}
+
+	grid := render.Grid(b)
+	for _, row := range grid {
+		for _, slot := range row {
+			if slot == nil {
+				continue
+			}
+			z := Zone{Row: slot.Row, Col: slot.Col, Con: slot.Con, Loc: slot.Loc, Seq: slot.Seq,
+				Label: slot.Label(b), Pile: slot.Pile(), Count: slot.Count(b)}
+			if c := slot.Card(b); c != nil {
+				f := render.FaceOf(c, slot.Loc == state.LOC_MZONE, db)
+				if slot.Pile() {
+					f = render.HeldFace(c, db)
+					if slot.Loc != state.LOC_GRAVE {
+						f.FaceDown = f.FaceDown || c.Position&state.POS_FACEDOWN != 0
+					}
+				}
+				card := cardOf(f, c)
+				z.Card = &card
+			}
+			s.Zones = append(s.Zones, z)
+		}
+	}
+
+	for _, l := range b.Chains.Current() {
+		link := Link{Number: l.Number, Code: l.Code, Controller: l.Controller, Place: placeOf(l.CardPlace),
+			Outcome: l.Outcome.String(), Solving: l.Solving}
+		for _, t := range l.Targets {
+			link.Targets = append(link.Targets, *placeOf(t))
+		}
+		s.Chain = append(s.Chain, link)
+	}
+	return s
+}
+
+// Codes returns every card code the snapshot shows. A nil snapshot shows
+// none.
+func (s *Snapshot) Codes() []uint32 {
+	if s == nil {
+		return nil
+	}
+	var codes []uint32
+	add := func(cards []Card) {
+		for _, c := range cards {
+			if c.Code != 0 {
+				codes = append(codes, c.Code)
+			}
+		}
+	}
+	for _, p := range s.Players {
+		add(p.Hand)
+		add(p.Grave)
+		add(p.Banished)
+	}
+	for _, z := range s.Zones {
+		if z.Card != nil {
+			add([]Card{*z.Card})
+		}
+	}
+	for _, l := range s.Chain {
+		if l.Code != 0 {
+			codes = append(codes, l.Code)
+		}
+	}
+	return codes
+}
+
+func cardOf(f render.Face, c *state.Card) Card {
+	card := Card{Code: f.Code, FaceDown: f.FaceDown, Defense: f.Monster && f.Defense}
+	if f.Monster && f.Code != 0 {
+		card.Atk, card.Def, card.Rating = f.Atk, f.Def, f.Rating
+	}
+	for _, ctr := range c.Counters {
+		card.Counters = append(card.Counters, Counter{Type: ctr.Type, Count: ctr.Count})
+	}
+	return card
+}
+
+func held(cards []*state.Card, db carddb.Source) []Card {
+	out := make([]Card, 0, len(cards))
+	for _, c := range cards {
+		out = append(out, cardOf(render.HeldFace(c, db), c))
+	}
+	return out
+}
+
+func placeOf(p *duelpb.Place) *Place {
+	if p == nil {
+		return nil
+	}
+	return &Place{Con: int(p.GetCon() & 1), Loc: p.GetLoc(), Seq: int(p.GetSeq())}
+}